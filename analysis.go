@@ -0,0 +1,228 @@
+package graph
+
+import "sort"
+
+func isDone(iss issue) bool {
+	return categorizeStatus(iss.Status) == statusClosed
+}
+
+func isStarted(iss issue) bool {
+	return categorizeStatus(iss.Status) != statusBacklog
+}
+
+// pointsByStatus sums estimates per categorized status, the same breakdown as EpicStats in src/Graph.tsx
+func pointsByStatus(issues []issue) map[string]float64 {
+	result := map[string]float64{}
+	for _, iss := range issues {
+		status := categorizeStatus(iss.Status)
+		if status == statusResolvedOnStaging {
+			status = statusInProgress
+		}
+		result[status] += iss.Estimate
+	}
+	return result
+}
+
+// statusOrder sorts statuses by workflow position, then alphabetically for statuses unknown to the workflow
+func statusOrder(statuses []string) {
+	rank := map[string]int{statusBacklog: 0, statusInProgress: 1, statusResolvedOnStaging: 2, statusClosed: 3}
+	sort.Slice(statuses, func(i, j int) bool {
+		ri, iKnown := rank[statuses[i]]
+		rj, jKnown := rank[statuses[j]]
+		if iKnown && jKnown {
+			return ri < rj
+		}
+		if iKnown != jKnown {
+			return iKnown
+		}
+		return statuses[i] < statuses[j]
+	})
+}
+
+// openPath is the heaviest chain of unfinished issues ending at an issue, weighed by remaining expected estimate
+type openPath struct {
+	keys     []string
+	estimate float64
+	// variance is the summed PERT variance of the chain's three-point estimates
+	variance float64
+}
+
+// openPaths computes, for every unfinished issue, the heaviest chain of unfinished in-graph blockers leading up to
+// and including it. Back edges of cycles are ignored.
+func openPaths(issues []issue) map[string]openPath {
+	byKey := make(map[string]issue, len(issues))
+	for _, iss := range issues {
+		byKey[iss.Key] = iss
+	}
+
+	paths := map[string]openPath{}
+	visiting := map[string]bool{}
+	var pathTo func(key string) openPath
+	pathTo = func(key string) openPath {
+		if p, ok := paths[key]; ok {
+			return p
+		}
+		iss := byKey[key]
+		visiting[key] = true
+		best := openPath{}
+		for _, blockedBy := range iss.blockedByKeys {
+			blocker, inGraph := byKey[blockedBy]
+			if !inGraph || isDone(blocker) || visiting[blockedBy] {
+				continue
+			}
+			if p := pathTo(blockedBy); p.estimate > best.estimate || (p.estimate == best.estimate && len(p.keys) > len(best.keys)) {
+				best = p
+			}
+		}
+		visiting[key] = false
+
+		p := openPath{
+			keys:     append(append([]string{}, best.keys...), key),
+			estimate: best.estimate + expectedEstimate(iss),
+			variance: best.variance + estimateVariance(iss),
+		}
+		paths[key] = p
+		return p
+	}
+
+	for _, iss := range issues {
+		if !isDone(iss) {
+			pathTo(iss.Key)
+		}
+	}
+	return paths
+}
+
+// criticalPath is the heaviest chain of unfinished issues in the graph
+func criticalPath(issues []issue) openPath {
+	paths := openPaths(issues)
+	keys := make([]string, 0, len(paths))
+	for k := range paths {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	best := openPath{}
+	for _, k := range keys {
+		p := paths[k]
+		if p.estimate > best.estimate || (p.estimate == best.estimate && len(p.keys) > len(best.keys)) {
+			best = p
+		}
+	}
+	return best
+}
+
+// blockedChains returns the chains of unfinished issues that end in an unfinished issue that nothing else unfinished
+// is waiting on, ordered by key of the last issue
+func blockedChains(resp graphResponse) []openPath {
+	byKey := make(map[string]issue, len(resp.Issues))
+	for _, iss := range resp.Issues {
+		byKey[iss.Key] = iss
+	}
+	blocksOpenWork := func(key string) bool {
+		for _, blocked := range resp.Graph[key] {
+			if iss, ok := byKey[blocked]; ok && !isDone(iss) {
+				return true
+			}
+		}
+		return false
+	}
+
+	paths := openPaths(resp.Issues)
+	keys := make([]string, 0, len(paths))
+	for k := range paths {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := []openPath{}
+	for _, k := range keys {
+		if p := paths[k]; len(p.keys) > 1 && !blocksOpenWork(k) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// newlyUnblocked returns the issues that haven't been started, had at least one blocker, and whose blockers are all
+// done
+func newlyUnblocked(issues []issue) []issue {
+	byKey := make(map[string]issue, len(issues))
+	for _, iss := range issues {
+		byKey[iss.Key] = iss
+	}
+
+	result := []issue{}
+	for _, iss := range issues {
+		if isStarted(iss) || len(iss.blockedByKeys) == 0 {
+			continue
+		}
+		unblocked := true
+		for _, blockedBy := range iss.blockedByKeys {
+			if blocker, ok := byKey[blockedBy]; !ok || !isDone(blocker) {
+				unblocked = false
+				break
+			}
+		}
+		if unblocked {
+			result = append(result, iss)
+		}
+	}
+	return result
+}
+
+// topologicalOrder returns the unfinished issues ordered so that every issue comes after its unfinished blockers, with
+// ties broken by key
+func topologicalOrder(issues []issue) []issue {
+	return topologicalOrderBy(issues, func(a, b issue) bool { return a.Key < b.Key })
+}
+
+// topologicalOrderBy returns the unfinished issues ordered so that every issue comes after its unfinished blockers,
+// picking the least issue among those available at each step. Issues on a cycle are appended in the same order once
+// nothing else is available.
+func topologicalOrderBy(issues []issue, less func(a, b issue) bool) []issue {
+	open := map[string]issue{}
+	for _, iss := range issues {
+		if !isDone(iss) {
+			open[iss.Key] = iss
+		}
+	}
+	pending := map[string]int{}
+	for k, iss := range open {
+		for _, blockedBy := range iss.blockedByKeys {
+			if _, ok := open[blockedBy]; ok {
+				pending[k]++
+			}
+		}
+	}
+
+	result := make([]issue, 0, len(open))
+	placed := map[string]bool{}
+	for len(result) < len(open) {
+		ready := []string{}
+		for k := range open {
+			if !placed[k] && pending[k] == 0 {
+				ready = append(ready, k)
+			}
+		}
+		if len(ready) == 0 {
+			for k := range open {
+				if !placed[k] {
+					ready = append(ready, k)
+				}
+			}
+		}
+		sort.Slice(ready, func(i, j int) bool { return less(open[ready[i]], open[ready[j]]) })
+		next := ready[0]
+		placed[next] = true
+		result = append(result, open[next])
+		for _, iss := range open {
+			for _, blockedBy := range iss.blockedByKeys {
+				if blockedBy == next {
+					pending[iss.Key]--
+				}
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testGraph is JG-1 -> JG-2 -> JG-4 and JG-1 -> JG-3 -> JG-4, with JG-1 closed and JG-5 standalone
+func testGraph() graphResponse {
+	issues := []issue{
+		{Key: "JG-1", Status: "Closed", Estimate: 8},
+		{Key: "JG-2", Status: "In Progress", Estimate: 5, blockedByKeys: []string{"JG-1"}},
+		{Key: "JG-3", Status: "Backlog", Estimate: 2, blockedByKeys: []string{"JG-1"}},
+		{Key: "JG-4", Status: "Ready for Dev", Estimate: 3, blockedByKeys: []string{"JG-2", "JG-3"}},
+		{Key: "JG-5", Status: "Resolved, on staging", Estimate: 1},
+	}
+	return graphResponse{Issues: issues, Graph: issuesToBlocksGraph(issues)}
+}
+
+func Test_pointsByStatus(t *testing.T) {
+	expected := map[string]float64{
+		statusClosed:     8,
+		statusInProgress: 6,
+		statusBacklog:    5,
+	}
+	assert.Equal(t, expected, pointsByStatus(testGraph().Issues))
+}
+
+func Test_criticalPath(t *testing.T) {
+	t.Run("heaviest unfinished chain", func(t *testing.T) {
+		p := criticalPath(testGraph().Issues)
+		assert.Equal(t, []string{"JG-2", "JG-4"}, p.keys)
+		assert.Equal(t, 8.0, p.estimate)
+	})
+
+	t.Run("three-point estimates", func(t *testing.T) {
+		issues := []issue{
+			{Key: "JG-1", Estimate: 5, ThreePoint: &threePointEstimate{Optimistic: 2, Likely: 3, Pessimistic: 10}},
+			{Key: "JG-2", Estimate: 4, blockedByKeys: []string{"JG-1"}},
+			{Key: "JG-3", Estimate: 8},
+		}
+		p := criticalPath(issues)
+		assert.Equal(t, []string{"JG-1", "JG-2"}, p.keys)
+		assert.InDelta(t, 8.0, p.estimate, 1e-9)
+		assert.InDelta(t, 16.0/9, p.variance, 1e-9)
+	})
+
+	t.Run("cycles don't recurse forever", func(t *testing.T) {
+		issues := []issue{
+			{Key: "JG-1", Estimate: 1, blockedByKeys: []string{"JG-2"}},
+			{Key: "JG-2", Estimate: 1, blockedByKeys: []string{"JG-1"}},
+		}
+		p := criticalPath(issues)
+		assert.Len(t, p.keys, 2)
+	})
+}
+
+func Test_blockedChains(t *testing.T) {
+	chains := blockedChains(testGraph())
+	if assert.Len(t, chains, 1) {
+		assert.Equal(t, []string{"JG-2", "JG-4"}, chains[0].keys)
+	}
+}
+
+func Test_newlyUnblocked(t *testing.T) {
+	unblocked := newlyUnblocked(testGraph().Issues)
+	if assert.Len(t, unblocked, 1) {
+		assert.Equal(t, "JG-3", unblocked[0].Key)
+	}
+}
@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type burnupDay struct {
+	Date      string  `json:"date"`
+	Scope     float64 `json:"scope"`
+	Completed float64 `json:"completed"`
+}
+
+type burnup struct {
+	InitialEstimate float64     `json:"initialEstimate"`
+	Days            []burnupDay `json:"days"`
+}
+
+// getEpicBurnup reconstructs an epic's daily scope and completed points from its issues' changelogs. Only issues
+// currently in the epic are considered.
+func getEpicBurnup(jc jiraClient, epicKey string, now time.Time) (burnup, error) {
+	epic, err := getSingleIssue(jc, epicKey)
+	if err != nil {
+		return burnup{}, err
+	}
+	histories, err := searchIssueHistories(jc, epicsJQL(jc, []string{epicKey}))
+	if err != nil {
+		return burnup{}, err
+	}
+	if len(histories.histories) == 0 {
+		return burnup{}, errBadStatus{http.StatusNotFound}
+	}
+
+	return burnup{
+		InitialEstimate: epic.InitialEstimate,
+		Days:            computeBurnup(histories, histories.estimateSource(jc.fieldConfig), now),
+	}, nil
+}
+
+// estimateSource is where issue estimates are recorded in changelogs, and how many recorded units make one estimate unit
+type estimateSource struct {
+	field   fieldMatcher
+	perUnit float64
+}
+
+func (h issueHistories) estimateSource(fc FieldConfig) estimateSource {
+	field := fc.estimateField()
+	return estimateSource{field: h.matcher(field), perUnit: 1 / fc.toEstimateUnit(field, 1)}
+}
+
+// estimateAt rewinds the issue's estimate to what it was at t
+func (h issueHistory) estimateAt(src estimateSource, t time.Time) float64 {
+	current := strconv.FormatFloat(h.issue.Estimate*src.perUnit, 'f', -1, 64)
+	recorded, _ := strconv.ParseFloat(h.valueAt(src.field, current, t), 64)
+	return recorded / src.perUnit
+}
+
+func computeBurnup(histories issueHistories, estimateSrc estimateSource, now time.Time) []burnupDay {
+	result := []burnupDay{}
+	start := earliestCreated(histories.histories)
+	if start.IsZero() {
+		start = now
+	}
+	for _, day := range days(start, now) {
+		point := burnupDay{Date: day.Format("2006-01-02")}
+		for _, h := range histories.histories {
+			if !h.existsAt(day) {
+				continue
+			}
+			estimate := h.estimateAt(estimateSrc, day)
+			point.Scope += estimate
+			if categorizeStatus(h.statusAt(day)) == statusClosed {
+				point.Completed += estimate
+			}
+		}
+		result = append(result, point)
+	}
+	return result
+}
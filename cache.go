@@ -0,0 +1,343 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// revalidateInterval is how often a stale cache entry is retried against Jira until Jira answers again
+const revalidateInterval = 30 * time.Second
+
+// staleness marks a response served from the cache, either because Jira couldn't be reached or because the entry is
+// younger than the cache's maxAge
+type staleness struct {
+	// Stale is set when Jira couldn't be reached
+	Stale bool `json:"stale,omitempty"`
+	// AgeSeconds is how long ago the cached response was fetched from Jira
+	AgeSeconds int `json:"ageSeconds,omitempty"`
+}
+
+type cacheEntry struct {
+	StoredAt time.Time       `json:"storedAt"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// responseCache keeps the last successful result of each Jira lookup on disk as '<dir>/<kind>/<key>.json', to fall
+// back on while Jira is unavailable
+type responseCache struct {
+	dir string
+	// maxAge, when set, serves entries younger than it without asking Jira; webhooks invalidate them when issues change
+	maxAge time.Duration
+
+	mu sync.Mutex
+	// revalidating holds the entries that are being retried in the background. Jira is known to be down for them, so
+	// they are served stale straight away.
+	revalidating map[string]bool
+	// invalidated is when each recently changed key was last invalidated. Lookups that were already running by then
+	// may have seen the issue before the change, so their results aren't stored.
+	invalidated map[string]time.Time
+}
+
+// invalidationMemory is how long an invalidation is remembered, which is well beyond the longest Jira lookup
+const invalidationMemory = time.Hour
+
+func newResponseCache(dir string, maxAge time.Duration) *responseCache {
+	return &responseCache{
+		dir:          dir,
+		maxAge:       maxAge,
+		revalidating: map[string]bool{},
+		invalidated:  map[string]time.Time{},
+	}
+}
+
+func (rc *responseCache) path(kind, key string) (string, bool) {
+	if !issueKeyPattern.MatchString(key) {
+		return "", false
+	}
+	return filepath.Join(rc.dir, kind, strings.ToUpper(key)+".json"), true
+}
+
+func (rc *responseCache) store(kind, key string, v interface{}) error {
+	path, ok := rc.path(kind, key)
+	if !ok {
+		return nil
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(cacheEntry{StoredAt: time.Now(), Value: value})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// load reads a stored value into dst, returning when it was stored
+func (rc *responseCache) load(kind, key string, dst interface{}) (time.Time, bool) {
+	path, ok := rc.path(kind, key)
+	if !ok {
+		return time.Time{}, false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		log.Printf("ignoring corrupt cache entry %s: %v", path, err)
+		return time.Time{}, false
+	}
+	if err := json.Unmarshal(entry.Value, dst); err != nil {
+		log.Printf("ignoring corrupt cache entry %s: %v", path, err)
+		return time.Time{}, false
+	}
+	return entry.StoredAt, true
+}
+
+// storeSince stores the result of a lookup that started at started, unless one of the keys it covers has been
+// invalidated since
+func (rc *responseCache) storeSince(kind, key string, v interface{}, started time.Time) error {
+	keys := lookupKeys(key, v)
+	if rc.invalidatedSince(started, keys) {
+		return nil
+	}
+	if err := rc.store(kind, key, v); err != nil {
+		return err
+	}
+	// an invalidation that ran while storing may have missed the entry
+	if rc.invalidatedSince(started, keys) {
+		return rc.remove(kind, key)
+	}
+	return nil
+}
+
+func (rc *responseCache) invalidatedSince(started time.Time, keys []string) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for _, k := range keys {
+		if at, ok := rc.invalidated[strings.ToUpper(k)]; ok && !at.Before(started) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupKeys are the keys that invalidate the result of a lookup: its own key, and for a graph the keys of its issues
+// and epics
+func lookupKeys(key string, v interface{}) []string {
+	keys := []string{key}
+	if resp, ok := v.(graphResponse); ok {
+		for _, iss := range resp.Issues {
+			keys = append(keys, iss.Key, iss.EpicKey)
+		}
+	}
+	return keys
+}
+
+func (rc *responseCache) markInvalidated(keys map[string]bool, now time.Time) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for k, at := range rc.invalidated {
+		if now.Sub(at) > invalidationMemory {
+			delete(rc.invalidated, k)
+		}
+	}
+	for k := range keys {
+		rc.invalidated[strings.ToUpper(k)] = now
+	}
+}
+
+func (rc *responseCache) remove(kind, key string) error {
+	path, ok := rc.path(kind, key)
+	if !ok {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// invalidate removes the cached issues and graphs of the given keys, along with every cached graph that contains one
+// of the keys as an issue or an epic. It returns the keys of the removed graphs, once each even when both their epic
+// and milestone graphs were removed.
+func (rc *responseCache) invalidate(keys map[string]bool) ([]string, error) {
+	rc.markInvalidated(keys, time.Now())
+	for k := range keys {
+		if err := rc.remove("issues", k); err != nil {
+			return nil, err
+		}
+	}
+
+	removed := []string{}
+	seen := map[string]bool{}
+	for _, loader := range graphLoaders {
+		graphKeys, err := rc.invalidateGraphs(loader.cacheKind, keys)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range graphKeys {
+			if !seen[k] {
+				seen[k] = true
+				removed = append(removed, k)
+			}
+		}
+	}
+	return removed, nil
+}
+
+// invalidateGraphs removes the cached graphs of one kind that are, or contain, one of the keys
+func (rc *responseCache) invalidateGraphs(kind string, keys map[string]bool) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(rc.dir, kind))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		graphKey := strings.TrimSuffix(e.Name(), ".json")
+		var cached struct {
+			Issues []struct {
+				Key     string `json:"key"`
+				EpicKey string `json:"epicKey"`
+			} `json:"issues"`
+		}
+		affected := keys[graphKey]
+		if _, ok := rc.load(kind, graphKey, &cached); ok && !affected {
+			for _, iss := range cached.Issues {
+				if keys[iss.Key] || keys[iss.EpicKey] {
+					affected = true
+					break
+				}
+			}
+		}
+		if affected {
+			if err := rc.remove(kind, graphKey); err != nil {
+				return nil, err
+			}
+			removed = append(removed, graphKey)
+		}
+	}
+	return removed, nil
+}
+
+func (rc *responseCache) isRevalidating(kind, key string) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.revalidating[kind+"/"+key]
+}
+
+// revalidate retries lookup in the background until Jira answers, then stores the fresh result. At most one retry
+// loop runs per entry.
+func (rc *responseCache) revalidate(kind, key string, lookup func() (interface{}, error)) {
+	name := kind + "/" + key
+	rc.mu.Lock()
+	if rc.revalidating[name] {
+		rc.mu.Unlock()
+		return
+	}
+	rc.revalidating[name] = true
+	rc.mu.Unlock()
+
+	go func() {
+		defer func() {
+			rc.mu.Lock()
+			delete(rc.revalidating, name)
+			rc.mu.Unlock()
+		}()
+		for {
+			time.Sleep(revalidateInterval)
+			started := time.Now()
+			v, err := lookup()
+			if err == nil {
+				if err := rc.storeSince(kind, key, v, started); err != nil {
+					log.Printf("failed to cache %s: %v", name, err)
+				}
+				return
+			}
+			if !isUnavailable(err) {
+				return
+			}
+		}
+	}()
+}
+
+// cached runs lookup and caches its result. When Jira is unavailable, or is known to be while the entry revalidates,
+// the cached result is loaded into dst instead and its staleness returned.
+func (rc *responseCache) cached(kind, key string, dst interface{}, lookup func() (interface{}, error)) (staleness, error) {
+	if rc.isRevalidating(kind, key) {
+		if storedAt, ok := rc.load(kind, key, dst); ok {
+			return staleSince(storedAt), nil
+		}
+	}
+	if rc.maxAge > 0 {
+		if storedAt, ok := rc.load(kind, key, dst); ok && time.Since(storedAt) < rc.maxAge {
+			return staleness{AgeSeconds: int(time.Since(storedAt).Seconds())}, nil
+		}
+	}
+
+	started := time.Now()
+	v, err := lookup()
+	if err == nil {
+		if err := rc.storeSince(kind, key, v, started); err != nil {
+			log.Printf("failed to cache %s/%s: %v", kind, key, err)
+		}
+		assign(dst, v)
+		return staleness{}, nil
+	}
+	if !isUnavailable(err) {
+		return staleness{}, err
+	}
+	storedAt, ok := rc.load(kind, key, dst)
+	if !ok {
+		return staleness{}, err
+	}
+	log.Printf("serving cached %s/%s: %v", kind, key, err)
+	rc.revalidate(kind, key, lookup)
+	return staleSince(storedAt), nil
+}
+
+func staleSince(storedAt time.Time) staleness {
+	return staleness{Stale: true, AgeSeconds: int(time.Since(storedAt).Seconds())}
+}
+
+// assign copies a lookup's result into dst, which points to a value of the same type
+func assign(dst, v interface{}) {
+	reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(v))
+}
+
+// isUnavailable tells Jira being down, unreachable or rate limiting apart from Jira answering that a lookup is invalid,
+// the lookup being abandoned by the client, or any other failure
+func isUnavailable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var ebs errBadStatus
+	if errors.As(err, &ebs) {
+		return ebs.statusCode >= 500 || ebs.statusCode == http.StatusTooManyRequests
+	}
+	// failures to reach Jira at all, including timeouts
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_responseCache(t *testing.T) {
+	rc := newResponseCache(t.TempDir(), 0)
+	fresh := issue{Key: "JG-1", Summary: "Fresh"}
+
+	var iss issue
+	s, err := rc.cached("issues", "JG-1", &iss, func() (interface{}, error) { return fresh, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, staleness{}, s)
+	assert.Equal(t, fresh, iss)
+
+	t.Run("missing issues aren't served from the cache", func(t *testing.T) {
+		_, err := rc.cached("issues", "JG-1", &iss, func() (interface{}, error) { return issue{}, errBadStatus{404} })
+		assert.Equal(t, errBadStatus{404}, err)
+	})
+
+	t.Run("unavailable Jira is", func(t *testing.T) {
+		var stale issue
+		s, err := rc.cached("issues", "JG-1", &stale, func() (interface{}, error) {
+			return issue{}, &url.Error{Op: "Get", URL: "https://jira", Err: errors.New("connection refused")}
+		})
+		assert.NoError(t, err)
+		assert.True(t, s.Stale)
+		assert.Equal(t, "Fresh", stale.Summary)
+		assert.True(t, rc.isRevalidating("issues", "JG-1"))
+	})
+
+	t.Run("rate limited Jira is", func(t *testing.T) {
+		_, err := rc.cached("issues", "JG-3", &iss, func() (interface{}, error) { return fresh, nil })
+		assert.NoError(t, err)
+		s, err := rc.cached("issues", "JG-3", &iss, func() (interface{}, error) { return issue{}, errBadStatus{429} })
+		assert.NoError(t, err)
+		assert.True(t, s.Stale)
+	})
+
+	t.Run("other failures aren't", func(t *testing.T) {
+		_, err := rc.cached("issues", "JG-4", &iss, func() (interface{}, error) { return fresh, nil })
+		assert.NoError(t, err)
+		_, err = rc.cached("issues", "JG-4", &iss, func() (interface{}, error) { return issue{}, errors.New("unexpected response") })
+		assert.EqualError(t, err, "unexpected response")
+	})
+
+	t.Run("without a cached response", func(t *testing.T) {
+		_, err := rc.cached("issues", "JG-2", &iss, func() (interface{}, error) { return issue{}, errBadStatus{503} })
+		assert.Equal(t, errBadStatus{503}, err)
+	})
+}
+
+func Test_responseCacheInvalidate(t *testing.T) {
+	rc := newResponseCache(t.TempDir(), time.Hour)
+	epicIssues := []issue{{Key: "JG-2", EpicKey: "JG-1"}}
+	milestoneIssues := []issue{{Key: "JG-4", EpicKey: "JG-3"}, {Key: "JG-6", EpicKey: "JG-5"}}
+	assert.NoError(t, rc.store(epicGraphs.cacheKind, "JG-1", graphResponse{Issues: epicIssues, Graph: issuesToBlocksGraph(epicIssues)}))
+	assert.NoError(t, rc.store(milestoneGraphs.cacheKind, "JG-10", graphResponse{Issues: milestoneIssues, Graph: issuesToBlocksGraph(milestoneIssues)}))
+
+	// fresh entries are served without asking Jira
+	var resp graphResponse
+	_, err := rc.cached(milestoneGraphs.cacheKind, "JG-10", &resp, func() (interface{}, error) { return nil, errors.New("unexpected lookup") })
+	assert.NoError(t, err)
+	// but only for the same kind of graph
+	_, err = rc.cached(epicGraphs.cacheKind, "JG-10", &resp, func() (interface{}, error) { return nil, errBadStatus{404} })
+	assert.Equal(t, errBadStatus{404}, err)
+
+	removed, err := rc.invalidate(map[string]bool{"JG-6": true, "JG-5": true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"JG-10"}, removed)
+	_, ok := rc.load(epicGraphs.cacheKind, "JG-1", &resp)
+	assert.True(t, ok)
+}
+
+func Test_responseCacheInvalidateDuringLookup(t *testing.T) {
+	rc := newResponseCache(t.TempDir(), time.Hour)
+	before := []issue{{Key: "JG-2", EpicKey: "JG-1", Status: "Backlog"}}
+
+	// the webhook for JG-2 arrives while the epic is still being fetched from Jira
+	var resp graphResponse
+	_, err := rc.cached(epicGraphs.cacheKind, "JG-1", &resp, func() (interface{}, error) {
+		_, err := rc.invalidate(map[string]bool{"JG-2": true})
+		assert.NoError(t, err)
+		return graphResponse{Issues: before, Graph: issuesToBlocksGraph(before)}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, before, resp.Issues)
+	// so the result is served to this request, but not to the next one
+	_, ok := rc.load(epicGraphs.cacheKind, "JG-1", &resp)
+	assert.False(t, ok)
+
+	// lookups that start after the invalidation are cached as usual
+	_, err = rc.cached(epicGraphs.cacheKind, "JG-1", &resp, func() (interface{}, error) {
+		return graphResponse{Issues: before, Graph: issuesToBlocksGraph(before)}, nil
+	})
+	assert.NoError(t, err)
+	_, ok = rc.load(epicGraphs.cacheKind, "JG-1", &resp)
+	assert.True(t, ok)
+}
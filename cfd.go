@@ -0,0 +1,55 @@
+package graph
+
+import "time"
+
+type cfdDay struct {
+	Date   string             `json:"date"`
+	Counts map[string]int     `json:"counts"`
+	Points map[string]float64 `json:"points"`
+}
+
+type cumulativeFlow struct {
+	// Categories lists the status categories in workflow order, i.e. the bands of the diagram from bottom to top
+	Categories []string `json:"categories"`
+	Days       []cfdDay `json:"days"`
+}
+
+// computeCumulativeFlow counts the issues and points in each status category at the end of every day, rewinding each
+// issue's status and estimate with its changelog
+func computeCumulativeFlow(histories issueHistories, estimateSrc estimateSource, now time.Time) cumulativeFlow {
+	start := earliestCreated(histories.histories)
+	if start.IsZero() {
+		start = now
+	}
+
+	seen := map[string]bool{}
+	result := cumulativeFlow{Categories: []string{}, Days: []cfdDay{}}
+	for _, day := range days(start, now) {
+		point := cfdDay{Date: day.Format("2006-01-02"), Counts: map[string]int{}, Points: map[string]float64{}}
+		for _, h := range histories.histories {
+			if !h.existsAt(day) {
+				continue
+			}
+			category := categorizeStatus(h.statusAt(day))
+			point.Counts[category]++
+			point.Points[category] += h.estimateAt(estimateSrc, day)
+			if !seen[category] {
+				seen[category] = true
+				result.Categories = append(result.Categories, category)
+			}
+		}
+		result.Days = append(result.Days, point)
+	}
+
+	// every day reports every category, so that the bands are continuous
+	for _, d := range result.Days {
+		for _, category := range result.Categories {
+			if _, ok := d.Counts[category]; !ok {
+				d.Counts[category] = 0
+				d.Points[category] = 0
+			}
+		}
+	}
+	statusOrder(result.Categories)
+	return result
+}
@@ -0,0 +1,229 @@
+package graph
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// change is a single field update from an issue's changelog
+type change struct {
+	At         time.Time
+	Field      string
+	FieldID    string
+	From       string
+	FromString string
+	To         string
+	ToString   string
+}
+
+// issueHistory is an issue's current state plus the changes that led to it, oldest first
+type issueHistory struct {
+	issue   issue
+	created time.Time
+	changes []change
+}
+
+// fieldMatcher recognizes the changelog entries of one field. Jira Cloud identifies fields in changelogs by ID while
+// Jira Server only uses display names, so both are checked.
+type fieldMatcher struct {
+	id   string
+	name string
+}
+
+func (m fieldMatcher) matches(c change) bool {
+	if len(c.FieldID) > 0 && c.FieldID == m.id {
+		return true
+	}
+	return c.Field == m.name || c.Field == m.id
+}
+
+var statusField = fieldMatcher{id: "status", name: "status"}
+
+// valueAt rewinds the field's current value to what it was at t, by undoing every later change
+func (h issueHistory) valueAt(m fieldMatcher, current string, t time.Time) string {
+	value := current
+	for i := len(h.changes) - 1; i >= 0; i-- {
+		c := h.changes[i]
+		if !c.At.After(t) {
+			break
+		}
+		if m.matches(c) {
+			value = c.FromString
+		}
+	}
+	return value
+}
+
+// changesOf returns the changes to a field, oldest first
+func (h issueHistory) changesOf(m fieldMatcher) []change {
+	result := []change{}
+	for _, c := range h.changes {
+		if m.matches(c) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func (h issueHistory) statusAt(t time.Time) string {
+	return h.valueAt(statusField, h.issue.Status, t)
+}
+
+// existsAt reports whether the issue had been created by t
+func (h issueHistory) existsAt(t time.Time) bool {
+	return !h.created.After(t)
+}
+
+func parseChangelog(r gjson.Result) []change {
+	result := []change{}
+	for _, history := range r.Get("changelog.histories").Array() {
+		at, err := parseJiraTime(history.Get("created").String())
+		if err != nil {
+			log.Printf("bad changelog entry for %s: %v", r.Get("key").String(), err)
+			continue
+		}
+		for _, item := range history.Get("items").Array() {
+			result = append(result, change{
+				At:         at,
+				Field:      item.Get("field").String(),
+				FieldID:    item.Get("fieldId").String(),
+				From:       item.Get("from").String(),
+				FromString: item.Get("fromString").String(),
+				To:         item.Get("to").String(),
+				ToString:   item.Get("toString").String(),
+			})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].At.Before(result[j].At) })
+	return result
+}
+
+// issueHistories is the result of a changelog search, with the display names of the fields in it
+type issueHistories struct {
+	histories  []issueHistory
+	fieldNames map[string]string
+}
+
+func (h issueHistories) matcher(fieldID string) fieldMatcher {
+	return fieldMatcher{id: fieldID, name: h.fieldNames[fieldID]}
+}
+
+// searchIssueHistories fetches the issues matching jql along with their changelogs. Jira only returns the most recent
+// changelog entries of each issue with search results, so very long-lived issues may be missing early history.
+func searchIssueHistories(jc jiraClient, jql string) (issueHistories, error) {
+	result := issueHistories{histories: []issueHistory{}, fieldNames: map[string]string{}}
+	fields := append(jc.getRequestFields(), "created")
+	err := searchPages(jc, jql, fields, []string{"changelog", "names"}, func(page gjson.Result) {
+		page.Get("names").ForEach(func(id, name gjson.Result) bool {
+			result.fieldNames[id.String()] = name.String()
+			return true
+		})
+		for _, parsedIssue := range page.Get("issues").Array() {
+			created, err := parseJiraTime(parsedIssue.Get("fields.created").String())
+			if err != nil {
+				log.Printf("bad created date for %s: %v", parsedIssue.Get("key").String(), err)
+			}
+			result.histories = append(result.histories, issueHistory{
+				issue:   jc.unmarshallLinkedIssue(parsedIssue),
+				created: created,
+				changes: parseChangelog(parsedIssue),
+			})
+		}
+	})
+	if err != nil {
+		return issueHistories{}, err
+	}
+	return result, nil
+}
+
+// days lists the end of each day from the day of start through the day of end, in end's location
+func days(start, end time.Time) []time.Time {
+	loc := end.Location()
+	start = start.In(loc)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	result := []time.Time{}
+	for !day.After(end) {
+		endOfDay := day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		if endOfDay.After(end) {
+			endOfDay = end
+		}
+		result = append(result, endOfDay)
+		day = day.AddDate(0, 0, 1)
+	}
+	return result
+}
+
+// earliestCreated is when the first of the issues was created
+func earliestCreated(histories []issueHistory) time.Time {
+	earliest := time.Time{}
+	for _, h := range histories {
+		if !h.created.IsZero() && (earliest.IsZero() || h.created.Before(earliest)) {
+			earliest = h.created
+		}
+	}
+	return earliest
+}
+
+// linkField matches issue link changes, which Jira records on both linked issues
+var linkField = fieldMatcher{id: "issuelinks", name: "Link"}
+
+// blockedByLinkText identifies the blocked side of a Blocks link in changelog descriptions, e.g.
+// 'This issue is blocked by JG-2'
+const blockedByLinkText = "is blocked by"
+
+// blockedByAt rewinds the issue's blockers to what they were at t, by undoing every later Blocks link change
+func (h issueHistory) blockedByAt(t time.Time) []string {
+	blockers := map[string]bool{}
+	for _, k := range h.issue.blockedByKeys {
+		blockers[k] = true
+	}
+	for i := len(h.changes) - 1; i >= 0; i-- {
+		c := h.changes[i]
+		if !c.At.After(t) {
+			break
+		}
+		if !linkField.matches(c) {
+			continue
+		}
+		if len(c.To) > 0 && strings.Contains(c.ToString, blockedByLinkText) {
+			delete(blockers, c.To)
+		}
+		if len(c.From) > 0 && strings.Contains(c.FromString, blockedByLinkText) {
+			blockers[c.From] = true
+		}
+	}
+
+	result := make([]string, 0, len(blockers))
+	for k := range blockers {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// searchIssuesAsOf finds the issues matching jql now and rewinds their status, estimate and blockers to jc.asOf.
+// Issues created since are left out; other fields keep their current values.
+func searchIssuesAsOf(jc jiraClient, jql string) ([]issue, error) {
+	histories, err := searchIssueHistories(jc, jql)
+	if err != nil {
+		return nil, err
+	}
+	estimateSrc := histories.estimateSource(jc.fieldConfig)
+
+	result := []issue{}
+	for _, h := range histories.histories {
+		if !h.existsAt(jc.asOf) {
+			continue
+		}
+		iss := h.issue
+		iss.Status = h.statusAt(jc.asOf)
+		iss.Estimate = h.estimateAt(estimateSrc, jc.asOf)
+		iss.blockedByKeys = h.blockedByAt(jc.asOf)
+		result = append(result, iss)
+	}
+	return result, nil
+}
@@ -0,0 +1,96 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func Test_parseChangelog(t *testing.T) {
+	raw := `{"key":"JG-2","changelog":{"histories":[
+		{"created":"2021-10-14T09:00:00.000+0000","items":[{"field":"Story Points","fieldtype":"custom","fieldId":"customfield_10031","from":null,"fromString":null,"to":null,"toString":"3"}]},
+		{"created":"2021-10-12T09:00:00.000+0000","items":[{"field":"status","fieldtype":"jira","fieldId":"status","from":"10000","fromString":"Backlog","to":"3","toString":"In Progress"}]}
+	]}}`
+	changes := parseChangelog(gjson.Parse(raw))
+	expected := []change{
+		{At: time.Date(2021, 10, 12, 9, 0, 0, 0, time.UTC), Field: "status", FieldID: "status", From: "10000", FromString: "Backlog", To: "3", ToString: "In Progress"},
+		{At: time.Date(2021, 10, 14, 9, 0, 0, 0, time.UTC), Field: "Story Points", FieldID: "customfield_10031", ToString: "3"},
+	}
+	assert.Len(t, changes, 2)
+	for i := range expected {
+		assert.True(t, expected[i].At.Equal(changes[i].At))
+		changes[i].At = expected[i].At
+	}
+	assert.Equal(t, expected, changes)
+}
+
+func Test_computeBurnup(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2021, 10, d, h, 0, 0, 0, time.UTC) }
+	estimate := estimateSource{field: fieldMatcher{id: "customfield_10031", name: "Story Points"}, perUnit: 1}
+	histories := issueHistories{histories: []issueHistory{
+		{
+			issue:   issue{Key: "JG-1", Status: "Closed", Estimate: 5},
+			created: day(1, 9),
+			changes: []change{
+				{At: day(2, 9), Field: "Story Points", FromString: "3", ToString: "5"},
+				{At: day(3, 9), Field: "status", FromString: "In Progress", ToString: "Closed"},
+			},
+		},
+		{
+			issue:   issue{Key: "JG-2", Status: "Backlog", Estimate: 2},
+			created: day(2, 12),
+		},
+	}}
+
+	expected := []burnupDay{
+		{Date: "2021-10-01", Scope: 3, Completed: 0},
+		{Date: "2021-10-02", Scope: 7, Completed: 0},
+		{Date: "2021-10-03", Scope: 7, Completed: 5},
+	}
+	assert.Equal(t, expected, computeBurnup(histories, estimate, day(3, 18)))
+}
+
+func Test_blockedByAt(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2021, 10, d, 9, 0, 0, 0, time.UTC) }
+	h := issueHistory{
+		issue: issue{Key: "JG-1", blockedByKeys: []string{"JG-3"}},
+		changes: []change{
+			{At: day(2), Field: "Link", To: "JG-2", ToString: "This issue is blocked by JG-2"},
+			{At: day(3), Field: "Link", To: "JG-4", ToString: "This issue blocks JG-4"},
+			{At: day(4), Field: "Link", From: "JG-2", FromString: "This issue is blocked by JG-2"},
+			{At: day(5), Field: "Link", To: "JG-3", ToString: "This issue is blocked by JG-3"},
+		},
+	}
+	assert.Equal(t, []string{}, h.blockedByAt(day(1)))
+	assert.Equal(t, []string{"JG-2"}, h.blockedByAt(day(3)))
+	assert.Equal(t, []string{"JG-3"}, h.blockedByAt(day(6)))
+}
+
+func Test_computeCumulativeFlow(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2021, 10, d, h, 0, 0, 0, time.UTC) }
+	estimate := estimateSource{field: fieldMatcher{id: "customfield_10031", name: "Story Points"}, perUnit: 1}
+	histories := issueHistories{histories: []issueHistory{
+		{
+			issue:   issue{Key: "JG-1", Status: "Closed", Estimate: 3},
+			created: day(1, 9),
+			changes: []change{
+				{At: day(2, 9), Field: "status", FromString: "Backlog", ToString: "In Progress"},
+				{At: day(3, 9), Field: "status", FromString: "In Progress", ToString: "Closed"},
+			},
+		},
+		{
+			issue:   issue{Key: "JG-2", Status: "Ready for Dev", Estimate: 2},
+			created: day(2, 12),
+		},
+	}}
+
+	flow := computeCumulativeFlow(histories, estimate, day(3, 18))
+	assert.Equal(t, []string{statusBacklog, statusInProgress, statusClosed}, flow.Categories)
+	if assert.Len(t, flow.Days, 3) {
+		assert.Equal(t, map[string]int{statusBacklog: 1, statusInProgress: 0, statusClosed: 0}, flow.Days[0].Counts)
+		assert.Equal(t, map[string]float64{statusBacklog: 2, statusInProgress: 3, statusClosed: 0}, flow.Days[1].Points)
+		assert.Equal(t, map[string]int{statusBacklog: 1, statusInProgress: 0, statusClosed: 1}, flow.Days[2].Counts)
+	}
+}
@@ -0,0 +1,173 @@
+package graph
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	riskOverdue = "overdue"
+	riskAtRisk  = "atRisk"
+	riskOnTrack = "onTrack"
+)
+
+type fixVersion struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Released    bool      `json:"released"`
+	ReleaseDate time.Time `json:"releaseDate"`
+}
+
+func parseFixVersion(r gjson.Result) fixVersion {
+	releaseDate, err := parseJiraDay(r.Get("releaseDate").String())
+	if err != nil {
+		log.Printf("bad release date for version %s: %v", r.Get("id").String(), err)
+	}
+	return fixVersion{
+		ID:          r.Get("id").String(),
+		Name:        r.Get("name").String(),
+		Released:    r.Get("released").Bool(),
+		ReleaseDate: releaseDate,
+	}
+}
+
+func getVersion(jc jiraClient, id string) (fixVersion, error) {
+	resp, err := jc.Get(fmt.Sprintf("/rest/api/2/version/%s", id), url.Values{})
+	if err != nil {
+		return fixVersion{}, err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fixVersion{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fixVersion{}, errBadStatus{resp.StatusCode}
+	}
+	return parseFixVersion(gjson.ParseBytes(b)), nil
+}
+
+// resolveReleaseDates replaces the issues' fix versions with the versions API's view of them, which carries release
+// dates that the issue fields may omit. Each version is fetched once.
+func resolveReleaseDates(jc jiraClient, issues []issue) ([]issue, error) {
+	versions := map[string]fixVersion{}
+	result := make([]issue, len(issues))
+	for i, iss := range issues {
+		resolved := make([]fixVersion, len(iss.FixVersions))
+		for j, v := range iss.FixVersions {
+			if _, ok := versions[v.ID]; !ok {
+				fetched, err := getVersion(jc, v.ID)
+				if err != nil {
+					return nil, err
+				}
+				versions[v.ID] = fetched
+			}
+			resolved[j] = versions[v.ID]
+		}
+		iss.FixVersions = resolved
+		result[i] = iss
+	}
+	return result, nil
+}
+
+// issueDeadline is the earliest of an issue's due date and the release dates of its unreleased fix versions, with a
+// description of where it came from
+func issueDeadline(iss issue) (time.Time, string) {
+	deadline, source := iss.DueDate, "due date"
+	for _, v := range iss.FixVersions {
+		if v.Released || v.ReleaseDate.IsZero() {
+			continue
+		}
+		if deadline.IsZero() || v.ReleaseDate.Before(deadline) {
+			deadline, source = v.ReleaseDate, "release "+v.Name
+		}
+	}
+	return deadline, source
+}
+
+type deadlineRisk struct {
+	Key            string    `json:"key"`
+	Summary        string    `json:"summary"`
+	Deadline       time.Time `json:"deadline"`
+	DeadlineSource string    `json:"deadlineSource"`
+	// Remaining is the expected estimate of the issue and its unfinished upstream blockers, and RemainingLow the low
+	// end of its 90% range
+	Remaining    float64 `json:"remaining"`
+	RemainingLow float64 `json:"remainingLow"`
+	// Available is the points the team completes between now and the end of the deadline day
+	Available float64  `json:"available"`
+	Blockers  []string `json:"blockers"`
+	Risk      string   `json:"risk"`
+}
+
+type deadlineAnalysis struct {
+	// Rate is the points the team completes per day
+	Rate  float64        `json:"rate"`
+	Risks []deadlineRisk `json:"risks"`
+}
+
+// analyzeDeadlines checks every unfinished issue with a deadline against the work that has to happen first: the issue
+// itself and all of its unfinished upstream blockers, done one after another at the team's rate. An issue is at risk
+// when even the optimistic end of that work doesn't fit before its deadline.
+func analyzeDeadlines(resp graphResponse, rate float64, now time.Time) deadlineAnalysis {
+	byKey := make(map[string]issue, len(resp.Issues))
+	for _, iss := range resp.Issues {
+		byKey[iss.Key] = iss
+	}
+
+	result := deadlineAnalysis{Rate: rate, Risks: []deadlineRisk{}}
+	for _, iss := range sortedByKey(resp.Issues) {
+		deadline, source := issueDeadline(iss)
+		if isDone(iss) || deadline.IsZero() {
+			continue
+		}
+
+		blockers := []string{}
+		remaining, variance := 0.0, 0.0
+		for k := range upstreamKeys(resp.Issues, iss.Key) {
+			upstream, ok := byKey[k]
+			if !ok || isDone(upstream) {
+				continue
+			}
+			remaining += expectedEstimate(upstream)
+			variance += estimateVariance(upstream)
+			if k != iss.Key {
+				blockers = append(blockers, k)
+			}
+		}
+		sort.Strings(blockers)
+		low, _ := confidenceRange(remaining, variance)
+
+		// deadlines are days, so work may continue until the end of the day
+		cutoff := deadline.AddDate(0, 0, 1)
+		available := math.Max(0, cutoff.Sub(now).Hours()/24*rate)
+		risk := riskOnTrack
+		if !now.Before(cutoff) {
+			risk = riskOverdue
+		} else if low > available {
+			risk = riskAtRisk
+		}
+
+		result.Risks = append(result.Risks, deadlineRisk{
+			Key:            iss.Key,
+			Summary:        iss.Summary,
+			Deadline:       deadline,
+			DeadlineSource: source,
+			Remaining:      remaining,
+			RemainingLow:   low,
+			Available:      available,
+			Blockers:       blockers,
+			Risk:           risk,
+		})
+	}
+	return result
+}
@@ -0,0 +1,35 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_analyzeDeadlines(t *testing.T) {
+	now := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	release := fixVersion{ID: "1", Name: "1.0", ReleaseDate: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)}
+	issues := []issue{
+		{Key: "JG-1", Status: "Backlog", Estimate: 3},
+		{Key: "JG-2", Status: "Backlog", Estimate: 2, blockedByKeys: []string{"JG-1"}, FixVersions: []fixVersion{release}},
+		{Key: "JG-3", Status: "Backlog", Estimate: 1, DueDate: time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), FixVersions: []fixVersion{release}},
+		{Key: "JG-4", Status: "Backlog", Estimate: 1, DueDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Key: "JG-5", Status: "Closed", Estimate: 1, DueDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	resp := graphResponse{Issues: issues, Graph: issuesToBlocksGraph(issues)}
+
+	// four days until the end of the release day, at one point per day
+	analysis := analyzeDeadlines(resp, 1, now)
+	risks := map[string]string{}
+	for _, r := range analysis.Risks {
+		risks[r.Key] = r.Risk
+	}
+	assert.Equal(t, map[string]string{"JG-2": riskAtRisk, "JG-3": riskOnTrack, "JG-4": riskOverdue}, risks)
+	if assert.Len(t, analysis.Risks, 3) {
+		assert.Equal(t, []string{"JG-1"}, analysis.Risks[0].Blockers)
+		assert.Equal(t, 5.0, analysis.Risks[0].Remaining)
+		// the release is earlier than the due date
+		assert.Equal(t, "release 1.0", analysis.Risks[1].DeadlineSource)
+	}
+}
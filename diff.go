@@ -0,0 +1,173 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type diffIssue struct {
+	Key     string `json:"key"`
+	Summary string `json:"summary"`
+}
+
+type issueChange struct {
+	Key     string `json:"key"`
+	Summary string `json:"summary"`
+	// Field is status, estimate, epic or flagged
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type graphDiff struct {
+	From          time.Time     `json:"from"`
+	To            time.Time     `json:"to"`
+	AddedIssues   []diffIssue   `json:"addedIssues"`
+	RemovedIssues []diffIssue   `json:"removedIssues"`
+	AddedEdges    [][2]string   `json:"addedEdges"`
+	RemovedEdges  [][2]string   `json:"removedEdges"`
+	Changes       []issueChange `json:"changes"`
+}
+
+// diffGraphs compares the issues, blocks edges, and issues' status, estimate, epic and flag between two graphs captured
+// at from and to
+func diffGraphs(before, after graphResponse, from, to time.Time) graphDiff {
+	result := graphDiff{
+		From:          from,
+		To:            to,
+		AddedIssues:   []diffIssue{},
+		RemovedIssues: []diffIssue{},
+		AddedEdges:    [][2]string{},
+		RemovedEdges:  [][2]string{},
+		Changes:       []issueChange{},
+	}
+
+	beforeByKey := make(map[string]issue, len(before.Issues))
+	for _, iss := range before.Issues {
+		beforeByKey[iss.Key] = iss
+	}
+	afterByKey := make(map[string]issue, len(after.Issues))
+	for _, iss := range after.Issues {
+		afterByKey[iss.Key] = iss
+	}
+
+	for _, iss := range sortedByKey(after.Issues) {
+		old, ok := beforeByKey[iss.Key]
+		if !ok {
+			result.AddedIssues = append(result.AddedIssues, diffIssue{Key: iss.Key, Summary: iss.Summary})
+			continue
+		}
+		fields := []struct{ name, from, to string }{
+			{"status", old.Status, iss.Status},
+			{"estimate", formatPoints(old.Estimate), formatPoints(iss.Estimate)},
+			{"epic", old.EpicKey, iss.EpicKey},
+			{"flagged", strconv.FormatBool(old.Flagged), strconv.FormatBool(iss.Flagged)},
+		}
+		for _, f := range fields {
+			if f.from != f.to {
+				result.Changes = append(result.Changes, issueChange{Key: iss.Key, Summary: iss.Summary, Field: f.name, From: f.from, To: f.to})
+			}
+		}
+	}
+	for _, iss := range sortedByKey(before.Issues) {
+		if _, ok := afterByKey[iss.Key]; !ok {
+			result.RemovedIssues = append(result.RemovedIssues, diffIssue{Key: iss.Key, Summary: iss.Summary})
+		}
+	}
+
+	edgeSet := func(g map[string][]string) map[[2]string]bool {
+		set := map[[2]string]bool{}
+		for _, e := range sortedEdges(g) {
+			set[e] = true
+		}
+		return set
+	}
+	beforeEdges, afterEdges := edgeSet(before.Graph), edgeSet(after.Graph)
+	for _, e := range sortedEdges(after.Graph) {
+		if !beforeEdges[e] {
+			result.AddedEdges = append(result.AddedEdges, e)
+		}
+	}
+	for _, e := range sortedEdges(before.Graph) {
+		if !afterEdges[e] {
+			result.RemovedEdges = append(result.RemovedEdges, e)
+		}
+	}
+	return result
+}
+
+func (d graphDiff) empty() bool {
+	return len(d.AddedIssues) == 0 && len(d.RemovedIssues) == 0 && len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0 && len(d.Changes) == 0
+}
+
+// markdown summarizes the diff, with additions and new values in bold and removals struck through
+func (d graphDiff) markdown(key string) string {
+	const layout = "2006-01-02 15:04 MST"
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s changes from %s to %s\n\n", key, d.From.Format(layout), d.To.Format(layout))
+	if d.empty() {
+		sb.WriteString("Nothing changed.\n")
+		return sb.String()
+	}
+
+	if len(d.AddedIssues) > 0 || len(d.RemovedIssues) > 0 {
+		sb.WriteString("## Issues\n\n")
+		for _, iss := range d.AddedIssues {
+			fmt.Fprintf(&sb, "- added **%s**\n", diffTitle(iss.Key, iss.Summary))
+		}
+		for _, iss := range d.RemovedIssues {
+			fmt.Fprintf(&sb, "- removed ~~%s~~\n", diffTitle(iss.Key, iss.Summary))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(d.AddedEdges) > 0 || len(d.RemovedEdges) > 0 {
+		sb.WriteString("## Dependencies\n\n")
+		for _, e := range d.AddedEdges {
+			fmt.Fprintf(&sb, "- added **%s blocks %s**\n", e[0], e[1])
+		}
+		for _, e := range d.RemovedEdges {
+			fmt.Fprintf(&sb, "- removed ~~%s blocks %s~~\n", e[0], e[1])
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(d.Changes) > 0 {
+		byField := map[string][]issueChange{}
+		for _, c := range d.Changes {
+			byField[c.Field] = append(byField[c.Field], c)
+		}
+		fields := make([]string, 0, len(byField))
+		for f := range byField {
+			fields = append(fields, f)
+		}
+		sort.Strings(fields)
+
+		for _, f := range fields {
+			fmt.Fprintf(&sb, "## %s changes\n\n", strings.ToUpper(f[:1])+f[1:])
+			for _, c := range byField[f] {
+				fmt.Fprintf(&sb, "- %s: ~~%s~~ → **%s**\n", diffTitle(c.Key, c.Summary), diffValue(c.From), diffValue(c.To))
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// diffTitle is the issue's key followed by its summary, if it has one
+func diffTitle(key, summary string) string {
+	if len(summary) == 0 {
+		return key
+	}
+	return key + " " + mdEscape(summary)
+}
+
+func diffValue(v string) string {
+	if len(v) == 0 {
+		return "none"
+	}
+	return mdEscape(v)
+}
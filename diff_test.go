@@ -0,0 +1,37 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_diffGraphs(t *testing.T) {
+	beforeIssues := []issue{
+		{Key: "JG-1", Status: "Backlog", Estimate: 3, EpicKey: "JG-10"},
+		{Key: "JG-2", Status: "Backlog", Estimate: 2, blockedByKeys: []string{"JG-1"}},
+	}
+	afterIssues := []issue{
+		{Key: "JG-1", Status: "In Progress", Estimate: 5, EpicKey: "JG-11"},
+		{Key: "JG-3", Status: "Backlog", Summary: "New", blockedByKeys: []string{"JG-1"}},
+	}
+	before := graphResponse{Issues: beforeIssues, Graph: issuesToBlocksGraph(beforeIssues)}
+	after := graphResponse{Issues: afterIssues, Graph: issuesToBlocksGraph(afterIssues)}
+
+	d := diffGraphs(before, after, time.Time{}, time.Time{})
+	assert.Equal(t, []diffIssue{{Key: "JG-3", Summary: "New"}}, d.AddedIssues)
+	assert.Equal(t, []diffIssue{{Key: "JG-2"}}, d.RemovedIssues)
+	assert.Equal(t, [][2]string{{"JG-1", "JG-3"}}, d.AddedEdges)
+	assert.Equal(t, [][2]string{{"JG-1", "JG-2"}}, d.RemovedEdges)
+	assert.Equal(t, []issueChange{
+		{Key: "JG-1", Field: "status", From: "Backlog", To: "In Progress"},
+		{Key: "JG-1", Field: "estimate", From: "3", To: "5"},
+		{Key: "JG-1", Field: "epic", From: "JG-10", To: "JG-11"},
+	}, d.Changes)
+
+	md := d.markdown("JG-100")
+	assert.Contains(t, md, "- JG-1: ~~Backlog~~ → **In Progress**")
+	assert.Contains(t, md, "- added **JG-3 New**")
+	assert.Contains(t, md, "- removed ~~JG-2~~")
+}
@@ -0,0 +1,268 @@
+package graph
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultEventsInterval = 30 * time.Second
+	minEventsInterval     = 5 * time.Second
+	// streamBacklog is how many events a stream can fall behind before it's dropped
+	streamBacklog = 16
+)
+
+// graphPoller fetches a graph on behalf of every stream of it, so that streams of the same graph share their Jira
+// requests. keys holds the graph's own key and its issues' and epics' keys, which decide whether a webhook event
+// wakes it.
+type graphPoller struct {
+	name    string
+	key     string
+	fetch   func() (graphResponse, error)
+	keys    map[string]bool
+	last    graphResponse
+	lastAt  time.Time
+	streams map[*graphStream]bool
+	wake    chan struct{}
+	stop    chan struct{}
+}
+
+// graphStream is a client streaming a graph's changes, polled at most every interval. Its first diff is against the
+// graph as fetched at since.
+type graphStream struct {
+	poller   *graphPoller
+	interval time.Duration
+	since    time.Time
+	// events is closed when the stream falls too far behind
+	events chan graphEvent
+}
+
+// graphEvent is a Server-Sent Event for streams, or a keep-alive comment when name is empty
+type graphEvent struct {
+	name string
+	data interface{}
+}
+
+// graphEvents runs a poller per streamed graph, and wakes the pollers affected by webhook events so they don't have to
+// wait for their next poll
+type graphEvents struct {
+	mu      sync.Mutex
+	pollers map[string]*graphPoller
+}
+
+func newGraphEvents() *graphEvents {
+	return &graphEvents{pollers: map[string]*graphPoller{}}
+}
+
+// join adds a stream to the named graph's poller, if it's running
+func (e *graphEvents) join(name string, interval time.Duration) (*graphStream, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	p, ok := e.pollers[name]
+	if !ok {
+		return nil, false
+	}
+	return p.add(interval), true
+}
+
+// start runs a poller for the named graph from its first fetch, and adds a stream to it. A poller started meanwhile by
+// another stream is joined instead.
+func (e *graphEvents) start(name, key string, interval time.Duration, first graphResponse, fetch func() (graphResponse, error)) *graphStream {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if p, ok := e.pollers[name]; ok {
+		return p.add(interval)
+	}
+	p := &graphPoller{
+		name:    name,
+		key:     key,
+		fetch:   fetch,
+		last:    first,
+		lastAt:  time.Now(),
+		streams: map[*graphStream]bool{},
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+	p.watch()
+	e.pollers[name] = p
+	go e.poll(p)
+	return p.add(interval)
+}
+
+func (p *graphPoller) add(interval time.Duration) *graphStream {
+	s := &graphStream{poller: p, interval: interval, since: p.lastAt, events: make(chan graphEvent, streamBacklog)}
+	p.streams[s] = true
+	return s
+}
+
+func (p *graphPoller) watch() {
+	p.keys = map[string]bool{strings.ToUpper(p.key): true}
+	for _, iss := range p.last.Issues {
+		p.keys[iss.Key] = true
+		p.keys[iss.EpicKey] = true
+	}
+}
+
+// leave removes a stream, stopping its poller when it was the last one
+func (e *graphEvents) leave(s *graphStream) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.remove(s)
+}
+
+func (e *graphEvents) remove(s *graphStream) {
+	p := s.poller
+	if !p.streams[s] {
+		return
+	}
+	delete(p.streams, s)
+	if len(p.streams) == 0 {
+		delete(e.pollers, p.name)
+		close(p.stop)
+	}
+}
+
+// interval is the shortest interval asked for by the poller's streams
+func (e *graphEvents) interval(p *graphPoller) time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	result := time.Duration(0)
+	for s := range p.streams {
+		if result == 0 || s.interval < result {
+			result = s.interval
+		}
+	}
+	if result == 0 {
+		return defaultEventsInterval
+	}
+	return result
+}
+
+// poll re-fetches the graph every interval, or when woken by a webhook, until the poller is stopped
+func (e *graphEvents) poll(p *graphPoller) {
+	for {
+		timer := time.NewTimer(e.interval(p))
+		select {
+		case <-p.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		case <-p.wake:
+			timer.Stop()
+		}
+
+		next, err := p.fetch()
+		e.publish(p, next, err, time.Now())
+	}
+}
+
+// publish sends every stream of the poller the diff against the previous fetch, or an error. A stream that has fallen
+// too far behind is dropped, and left to reconnect.
+func (e *graphEvents) publish(p *graphPoller, next graphResponse, err error, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	event := graphEvent{}
+	if err != nil {
+		event = graphEvent{name: "error", data: gin.H{"error": err.Error()}}
+	} else if d := diffGraphs(p.last, next, p.lastAt, now); !d.empty() {
+		event = graphEvent{name: "diff", data: d}
+		p.last, p.lastAt = next, now
+		p.watch()
+	}
+	for s := range p.streams {
+		select {
+		case s.events <- event:
+		default:
+			close(s.events)
+			e.remove(s)
+		}
+	}
+}
+
+// notify wakes every poller watching one of the keys. A poller that is already due to wake isn't woken twice.
+func (e *graphEvents) notify(keys map[string]bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, p := range e.pollers {
+		if !p.watchesAny(keys) {
+			continue
+		}
+		select {
+		case p.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (p *graphPoller) watchesAny(keys map[string]bool) bool {
+	for k := range keys {
+		if p.keys[k] {
+			return true
+		}
+	}
+	return false
+}
+
+// streamGraphEvents sends a graph's changes as Server-Sent Events. The graph is re-fetched every 'interval' seconds,
+// or as soon as a webhook reports a change to it, and each non-empty diff against the previous fetch is sent as a
+// 'diff' event. Streams of the same graph share one poller, which polls at the shortest interval among them.
+func (gc graphController) streamGraphEvents(loader graphLoader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.Param("key")
+		interval := defaultEventsInterval
+		seconds := 0
+		if err := positiveIntQuery(c, "interval", &seconds); err != nil {
+			respondWithError(c, err)
+			return
+		}
+		if seconds > 0 {
+			interval = time.Duration(seconds) * time.Second
+		}
+		if interval < minEventsInterval {
+			interval = minEventsInterval
+		}
+
+		name := loader.cacheKind + "/" + strings.ToUpper(key)
+		stream, ok := gc.events.join(name, interval)
+		if !ok {
+			first, err := gc.fetchGraph(gc.client(c), key, loader)
+			if err != nil {
+				respondWithError(c, err)
+				return
+			}
+			stream = gc.events.start(name, key, interval, first, func() (graphResponse, error) {
+				return gc.fetchGraph(gc.jc, key, loader)
+			})
+		}
+		defer gc.events.leave(stream)
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		c.SSEvent("ready", gin.H{"key": key, "at": stream.since})
+		c.Writer.Flush()
+
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case event, ok := <-stream.events:
+				if !ok {
+					return
+				}
+				if len(event.name) > 0 {
+					c.SSEvent(event.name, event.data)
+				} else {
+					// keeps proxies from timing out idle streams
+					c.Writer.WriteString(": no changes\n\n")
+				}
+				c.Writer.Flush()
+			}
+		}
+	}
+}
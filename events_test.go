@@ -0,0 +1,140 @@
+package graph
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_graphEventsNotify(t *testing.T) {
+	events := newGraphEvents()
+	unexpected := func() (graphResponse, error) { return graphResponse{}, errors.New("unexpected fetch") }
+	epic := events.start("epic-graphs/JG-1", "JG-1", time.Hour, graphResponse{Issues: []issue{{Key: "JG-2", EpicKey: "JG-1"}}}, unexpected)
+	other := events.start("epic-graphs/JG-5", "JG-5", time.Hour, graphResponse{Issues: []issue{{Key: "JG-6", EpicKey: "JG-5"}}}, unexpected)
+	defer events.leave(other)
+
+	// streams of the same graph share its poller
+	second, ok := events.join("epic-graphs/JG-1", time.Minute)
+	assert.True(t, ok)
+	assert.Same(t, epic.poller, second.poller)
+	assert.Equal(t, time.Minute, events.interval(epic.poller))
+
+	events.mu.Lock()
+	assert.True(t, epic.poller.watchesAny(map[string]bool{"JG-2": true}))
+	assert.True(t, epic.poller.watchesAny(map[string]bool{"JG-1": true}))
+	assert.False(t, other.poller.watchesAny(map[string]bool{"JG-2": true}))
+	events.mu.Unlock()
+
+	// the poller stops with its last stream
+	events.leave(epic)
+	events.leave(second)
+	_, ok = events.join("epic-graphs/JG-1", time.Minute)
+	assert.False(t, ok)
+	<-epic.poller.stop
+}
+
+func Test_graphEventsPublish(t *testing.T) {
+	events := newGraphEvents()
+	before := []issue{{Key: "JG-2", EpicKey: "JG-1", Status: "Backlog"}}
+	stream := events.start("epic-graphs/JG-1", "JG-1", time.Hour, graphResponse{Issues: before}, nil)
+	defer events.leave(stream)
+
+	after := []issue{{Key: "JG-2", EpicKey: "JG-1", Status: "In Progress"}, {Key: "JG-3", EpicKey: "JG-1"}}
+	events.publish(stream.poller, graphResponse{Issues: after, Graph: issuesToBlocksGraph(after)}, nil, time.Now())
+	event := <-stream.events
+	assert.Equal(t, "diff", event.name)
+	// the poller now watches the new issue too
+	events.mu.Lock()
+	assert.True(t, stream.poller.watchesAny(map[string]bool{"JG-3": true}))
+	events.mu.Unlock()
+
+	// a stream that stops reading is dropped once it has fallen too far behind
+	for i := 0; i <= streamBacklog; i++ {
+		events.publish(stream.poller, graphResponse{}, errors.New("timeout"), time.Now())
+	}
+	for range stream.events {
+	}
+	_, ok := events.join("epic-graphs/JG-1", time.Minute)
+	assert.False(t, ok)
+}
+
+// openEventStream connects to a Server-Sent Events endpoint, returning a function reading the next event's name and data
+func openEventStream(t *testing.T, ctx context.Context, url string) func() (string, string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := bufio.NewScanner(resp.Body)
+	return func() (string, string) {
+		event, data := "", ""
+		for lines.Scan() {
+			line := lines.Text()
+			if len(line) == 0 && len(event) > 0 {
+				break
+			}
+			if v := strings.TrimPrefix(line, "event:"); v != line {
+				event = v
+			} else if v := strings.TrimPrefix(line, "data:"); v != line {
+				data = v
+			}
+		}
+		return event, data
+	}
+}
+
+func Test_streamGraphEvents(t *testing.T) {
+	var mu sync.Mutex
+	status, fetches := "Backlog", 0
+	loader := graphLoader{cacheKind: "epic-graphs", load: func(jc jiraClient, key string) (graphResponse, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetches++
+		issues := []issue{{Key: "JG-2", EpicKey: key, Status: status}}
+		return graphResponse{Issues: issues, Graph: issuesToBlocksGraph(issues)}, nil
+	}}
+	gc := graphController{events: newGraphEvents()}
+	r := gin.New()
+	r.GET("/api/epics/:key/events", gc.streamGraphEvents(loader))
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	first := openEventStream(t, ctx, ts.URL+"/api/epics/JG-1/events")
+	event, data := first()
+	assert.Equal(t, "ready", event)
+	assert.Contains(t, data, `"key":"JG-1"`)
+	second := openEventStream(t, ctx, ts.URL+"/api/epics/JG-1/events")
+	event, _ = second()
+	assert.Equal(t, "ready", event)
+
+	// a webhook reporting a change to one of the graph's issues triggers a fetch without waiting for the interval
+	mu.Lock()
+	status = "In Progress"
+	mu.Unlock()
+	gc.events.notify(map[string]bool{"JG-2": true})
+	for _, next := range []func() (string, string){first, second} {
+		event, data = next()
+		assert.Equal(t, "diff", event)
+		assert.Contains(t, data, `"changes":[{"key":"JG-2","summary":"","field":"status","from":"Backlog","to":"In Progress"}]`)
+	}
+
+	// both streams were served by the same fetches
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, fetches)
+}
@@ -0,0 +1,184 @@
+package graph
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GraphQuery selects the issues that make up a graph. Exactly one of the keys or JQL should be set.
+type GraphQuery struct {
+	EpicKey      string
+	MilestoneKey string
+	JQL          string
+}
+
+func (q GraphQuery) validate() error {
+	set := 0
+	for _, v := range []string{q.EpicKey, q.MilestoneKey, q.JQL} {
+		if len(v) > 0 {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("exactly one of an epic key, a milestone key or JQL is required")
+	}
+	return nil
+}
+
+func getQueryGraph(jc jiraClient, q GraphQuery) (graphResponse, error) {
+	if err := q.validate(); err != nil {
+		return graphResponse{}, err
+	}
+	switch {
+	case len(q.EpicKey) > 0:
+		return getEpicGraph(jc, q.EpicKey)
+	case len(q.MilestoneKey) > 0:
+		return getMilestoneGraph(jc, q.MilestoneKey)
+	default:
+		return getJQLGraph(jc, q.JQL)
+	}
+}
+
+var exportFormats = map[string]func(io.Writer, graphResponse) error{
+	"json":    writeJSON,
+	"dot":     writeDOT,
+	"mermaid": writeMermaid,
+	"csv":     writeCSV,
+}
+
+// ExportFormats lists the formats accepted by Export
+func ExportFormats() []string {
+	formats := make([]string, 0, len(exportFormats))
+	for f := range exportFormats {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Export fetches the graph selected by q from Jira and writes it to w in the given format without starting a server
+func Export(user, pass, jiraHost string, fc FieldConfig, q GraphQuery, format string, w io.Writer) error {
+	write, ok := exportFormats[format]
+	if !ok {
+		return fmt.Errorf("unknown format %q; expected one of %s", format, strings.Join(ExportFormats(), ", "))
+	}
+	useStatusCategories(fc)
+	jc := jiraClient{
+		host:        jiraHost,
+		user:        user,
+		pass:        pass,
+		fieldConfig: fc,
+	}
+	resp, err := getQueryGraph(jc, q)
+	if err != nil {
+		return err
+	}
+	return write(w, resp)
+}
+
+func writeJSON(w io.Writer, resp graphResponse) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(resp)
+}
+
+// sortedEdges flattens a blocks graph into deterministic blocker -> blocked pairs
+func sortedEdges(blocksGraph map[string][]string) [][2]string {
+	blockers := make([]string, 0, len(blocksGraph))
+	for k := range blocksGraph {
+		blockers = append(blockers, k)
+	}
+	sort.Strings(blockers)
+
+	edges := [][2]string{}
+	for _, blocker := range blockers {
+		blocked := append([]string{}, blocksGraph[blocker]...)
+		sort.Strings(blocked)
+		for _, b := range blocked {
+			edges = append(edges, [2]string{blocker, b})
+		}
+	}
+	return edges
+}
+
+func writeDOT(w io.Writer, resp graphResponse) error {
+	var sb strings.Builder
+	sb.WriteString("digraph blocks {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	for _, iss := range resp.Issues {
+		label := fmt.Sprintf("%s\n%s\n%s", iss.Key, iss.Summary, iss.Status)
+		fmt.Fprintf(&sb, "  %s [label=%s];\n", strconv.Quote(iss.Key), strconv.Quote(label))
+	}
+	for _, e := range sortedEdges(resp.Graph) {
+		fmt.Fprintf(&sb, "  %s -> %s;\n", strconv.Quote(e[0]), strconv.Quote(e[1]))
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+var mermaidUnsafe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func mermaidID(key string) string {
+	return mermaidUnsafe.ReplaceAllString(key, "_")
+}
+
+// mermaidLabel escapes quotes, and folds line breaks that would end the node's statement
+func mermaidLabel(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	return strings.Join(strings.Fields(strings.NewReplacer("\r", " ", "\n", " ").Replace(s)), " ")
+}
+
+func writeMermaid(w io.Writer, resp graphResponse) error {
+	var sb strings.Builder
+	sb.WriteString("graph LR\n")
+	for _, iss := range resp.Issues {
+		fmt.Fprintf(&sb, "  %s[\"%s: %s\"]\n", mermaidID(iss.Key), iss.Key, mermaidLabel(iss.Summary))
+	}
+	for _, e := range sortedEdges(resp.Graph) {
+		fmt.Fprintf(&sb, "  %s --> %s\n", mermaidID(e[0]), mermaidID(e[1]))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeCSV(w io.Writer, resp graphResponse) error {
+	cw := csv.NewWriter(w)
+	header := []string{"key", "type", "summary", "status", "assignee", "estimate", "priority", "flagged", "epicKey", "epicName", "blockedBy", "blocks"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, iss := range resp.Issues {
+		blockedBy := append([]string{}, iss.blockedByKeys...)
+		sort.Strings(blockedBy)
+		blocks := append([]string{}, resp.Graph[iss.Key]...)
+		sort.Strings(blocks)
+
+		row := []string{
+			iss.Key,
+			iss.Type,
+			iss.Summary,
+			iss.Status,
+			iss.Assignee,
+			strconv.FormatFloat(iss.Estimate, 'f', -1, 64),
+			iss.Priority,
+			strconv.FormatBool(iss.Flagged),
+			iss.EpicKey,
+			iss.EpicName,
+			strings.Join(blockedBy, ";"),
+			strings.Join(blocks, ";"),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
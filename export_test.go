@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func exportFixture() graphResponse {
+	issues := []issue{
+		{Key: "JG-1", Summary: `Say "hi" [twice]`, Status: "Done", Estimate: 2.5},
+		{Key: "JG-2", Summary: "Two\r\nlines, and a comma", Status: "Backlog", EpicName: "Epic", blockedByKeys: []string{"JG-1"}},
+	}
+	return graphResponse{Issues: issues, Graph: issuesToBlocksGraph(issues)}
+}
+
+func Test_writeDOT(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, writeDOT(&buf, exportFixture()))
+	assert.Equal(t, `digraph blocks {
+  rankdir=LR;
+  "JG-1" [label="JG-1\nSay \"hi\" [twice]\nDone"];
+  "JG-2" [label="JG-2\nTwo\r\nlines, and a comma\nBacklog"];
+  "JG-1" -> "JG-2";
+}
+`, buf.String())
+}
+
+func Test_writeMermaid(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, writeMermaid(&buf, exportFixture()))
+	assert.Equal(t, `graph LR
+  JG_1["JG-1: Say #quot;hi#quot; [twice]"]
+  JG_2["JG-2: Two lines, and a comma"]
+  JG_1 --> JG_2
+`, buf.String())
+}
+
+func Test_writeCSV(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, writeCSV(&buf, exportFixture()))
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, []string{"JG-1", "", `Say "hi" [twice]`, "Done", "", "2.5", "", "false", "", "", "", "JG-2"}, rows[1])
+	// encoding/csv reads quoted line breaks back as '\n'
+	assert.Equal(t, []string{"JG-2", "", "Two\nlines, and a comma", "Backlog", "", "0", "", "false", "", "Epic", "JG-1", ""}, rows[2])
+}
+
+func Test_getQueryGraphEpicFailure(t *testing.T) {
+	searches := map[string]string{`"customfield_10014" IN (JG-1)`: "[" + fakeIssue("JG-2", "Story", "JG-1") + "]"}
+	resp, err := getQueryGraph(fakeJira{searches: searches, epics: map[string]string{"JG-1": "Epic"}}.client(t), GraphQuery{EpicKey: "JG-1"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Epic", resp.Issues[0].EpicName)
+	}
+
+	// an export with blank epic names is a failed export
+	_, err = getQueryGraph(fakeJira{searches: searches}.client(t), GraphQuery{EpicKey: "JG-1"})
+	assert.ErrorIs(t, err, errBadStatus{404})
+}
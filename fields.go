@@ -1,9 +1,99 @@
 package graph
 
+import "fmt"
+
+// Estimate modes select what an issue's estimate measures
+const (
+	// EstimatePoints reads the Estimate field, e.g. story points
+	EstimatePoints = "points"
+	// EstimateHours reads the remaining time-tracking estimate, in hours
+	EstimateHours = "hours"
+	// EstimateOriginal reads the original time-tracking estimate, in hours
+	EstimateOriginal = "original"
+)
+
+const secondsPerHour = 3600
+
+// timeTrackingFields are recorded in seconds
+var timeTrackingFields = map[string]bool{
+	"timeestimate":         true,
+	"timeoriginalestimate": true,
+	"timespent":            true,
+}
+
 type FieldConfig struct {
 	InitialEstimate string
 	Estimate        string
 	Flagged         string
 	Sprints         string
 	EpicLink        string
+	// Optional three-point estimate fields. Issues with all three set are weighed by their PERT estimate.
+	OptimisticEstimate  string
+	LikelyEstimate      string
+	PessimisticEstimate string
+	// EstimateMode is one of EstimatePoints (the default when empty), EstimateHours or EstimateOriginal
+	EstimateMode string
+	// HoursPerPoint, when set, converts between time-tracking values and points, e.g. for a time-tracking initial
+	// estimate with point estimates
+	HoursPerPoint float64
+	// StatusCategories maps workflow statuses to the Backlog, In Progress and Closed categories; statuses missing from
+	// it are a category of their own. When nil, the default workflow's statuses are mapped.
+	StatusCategories map[string]string
+}
+
+func (fc FieldConfig) Validate() error {
+	switch fc.EstimateMode {
+	case "", EstimatePoints, EstimateHours, EstimateOriginal:
+	default:
+		return fmt.Errorf("estimate mode must be one of %s, %s or %s", EstimatePoints, EstimateHours, EstimateOriginal)
+	}
+	if fc.HoursPerPoint < 0 {
+		return fmt.Errorf("hours per point must not be negative")
+	}
+	for status, category := range fc.StatusCategories {
+		switch category {
+		case statusBacklog, statusInProgress, statusClosed:
+		default:
+			return fmt.Errorf("the category of %q must be one of %s, %s or %s", status, statusBacklog, statusInProgress, statusClosed)
+		}
+	}
+	return nil
+}
+
+// estimateUnit is the unit of issue estimates: points or hours
+func (fc FieldConfig) estimateUnit() string {
+	if fc.EstimateMode == EstimateHours || fc.EstimateMode == EstimateOriginal {
+		return "hours"
+	}
+	return "points"
+}
+
+// estimateField is the field that issue estimates are read from
+func (fc FieldConfig) estimateField() string {
+	switch fc.EstimateMode {
+	case EstimateHours:
+		return "timeestimate"
+	case EstimateOriginal:
+		return "timeoriginalestimate"
+	}
+	return fc.Estimate
+}
+
+// toEstimateUnit converts a raw value of a field into the estimate unit. Time-tracking seconds become hours, and hours
+// and points convert into each other through HoursPerPoint when it's set. Without it, time-tracking values can't be
+// expressed in points and are left as they are.
+func (fc FieldConfig) toEstimateUnit(field string, raw float64) float64 {
+	if !timeTrackingFields[field] {
+		if fc.estimateUnit() == "hours" && fc.HoursPerPoint > 0 {
+			return raw * fc.HoursPerPoint
+		}
+		return raw
+	}
+	if fc.estimateUnit() == "points" {
+		if fc.HoursPerPoint > 0 {
+			return raw / secondsPerHour / fc.HoursPerPoint
+		}
+		return raw
+	}
+	return raw / secondsPerHour
 }
@@ -0,0 +1,216 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+const (
+	forecastThroughput = "throughput"
+	forecastVelocity   = "velocity"
+
+	defaultForecastTrials  = 1000
+	defaultForecastHistory = 12
+	// maxForecastTrials and maxForecastHistory bound the work and memory a single request can ask for
+	maxForecastTrials   = 100000
+	maxForecastHistory  = 104
+	defaultSprintLength = 14 * 24 * time.Hour
+)
+
+type forecastDates struct {
+	P50 time.Time `json:"p50"`
+	P85 time.Time `json:"p85"`
+	P95 time.Time `json:"p95"`
+}
+
+type forecast struct {
+	Method string `json:"method"`
+	Trials int    `json:"trials"`
+	// Samples are the points completed in each historical period that the simulation draws from
+	Samples    []float64                `json:"samples"`
+	PeriodDays float64                  `json:"periodDays"`
+	Remaining  float64                  `json:"remaining"`
+	Epics      map[string]forecastDates `json:"epics"`
+	Overall    forecastDates            `json:"overall"`
+}
+
+// forecastHistory is the capacity observed in past periods of equal length
+type forecastHistory struct {
+	samples []float64
+	period  time.Duration
+}
+
+// throughputHistory buckets the points of issues resolved in the last n weeks into weekly samples
+func throughputHistory(issues []issue, n int, now time.Time) forecastHistory {
+	week := 7 * 24 * time.Hour
+	samples := make([]float64, n)
+	start := now.Add(-time.Duration(n) * week)
+	for _, iss := range issues {
+		if !isDone(iss) || iss.Resolved.IsZero() || iss.Resolved.Before(start) || iss.Resolved.After(now) {
+			continue
+		}
+		bucket := int(iss.Resolved.Sub(start) / week)
+		if bucket >= n {
+			bucket = n - 1
+		}
+		samples[bucket] += iss.Estimate
+	}
+	return forecastHistory{samples: samples, period: week}
+}
+
+func isClosedSprint(s sprint) bool {
+	return s.State == "closed" || s.State == "CLOSED"
+}
+
+// sprintHistory credits the points of each done issue to the last sprint it was in, and returns the completed points
+// of the last n closed sprints seen in the issues
+func sprintHistory(issues []issue, n int) forecastHistory {
+	completed := map[int]float64{}
+	closed := map[int]sprint{}
+	for _, iss := range issues {
+		for _, s := range iss.Sprints {
+			if isClosedSprint(s) {
+				closed[s.ID] = s
+			}
+		}
+		if isDone(iss) && len(iss.Sprints) > 0 {
+			last := iss.Sprints[len(iss.Sprints)-1]
+			if isClosedSprint(last) {
+				completed[last.ID] += iss.Estimate
+			}
+		}
+	}
+
+	sprints := make([]sprint, 0, len(closed))
+	for _, s := range closed {
+		sprints = append(sprints, s)
+	}
+	sort.Slice(sprints, func(i, j int) bool { return sprints[i].EndDate.Before(sprints[j].EndDate) })
+	if len(sprints) > n {
+		sprints = sprints[len(sprints)-n:]
+	}
+
+	samples := make([]float64, len(sprints))
+	for i, s := range sprints {
+		samples[i] = completed[s.ID]
+	}
+	return forecastHistory{samples: samples, period: medianSprintLength(sprints)}
+}
+
+func medianSprintLength(sprints []sprint) time.Duration {
+	lengths := []time.Duration{}
+	for _, s := range sprints {
+		if !s.StartDate.IsZero() && s.EndDate.After(s.StartDate) {
+			lengths = append(lengths, s.EndDate.Sub(s.StartDate))
+		}
+	}
+	if len(lengths) == 0 {
+		return defaultSprintLength
+	}
+	sort.Slice(lengths, func(i, j int) bool { return lengths[i] < lengths[j] })
+	return lengths[len(lengths)/2]
+}
+
+// simulateCompletion runs one trial: each period's capacity is drawn from the history samples and is spent on issues
+// in blocking order. It returns the number of periods after which each issue is complete.
+func simulateCompletion(order []issue, samples []float64, rng *rand.Rand) map[string]float64 {
+	done := make(map[string]float64, len(order))
+	periods := 0
+	capacity, left := 0.0, 0.0
+	for _, iss := range order {
+		remaining := expectedEstimate(iss)
+		for remaining > left {
+			remaining -= left
+			capacity = samples[rng.Intn(len(samples))]
+			left = capacity
+			periods++
+		}
+		left -= remaining
+
+		elapsed := float64(periods)
+		if capacity > 0 {
+			elapsed = float64(periods-1) + (capacity-left)/capacity
+		}
+		done[iss.Key] = elapsed
+	}
+	return done
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+func runForecast(issues []issue, history forecastHistory, trials int, now time.Time, rng *rand.Rand) (forecast, error) {
+	total := 0.0
+	for _, s := range history.samples {
+		total += s
+	}
+	if total <= 0 {
+		return forecast{}, errors.New("no completed work in the history to forecast from")
+	}
+
+	order := topologicalOrder(issues)
+	remaining := 0.0
+	epicIssues := map[string][]string{}
+	for _, iss := range order {
+		remaining += expectedEstimate(iss)
+		epicIssues[iss.EpicKey] = append(epicIssues[iss.EpicKey], iss.Key)
+	}
+
+	epicPeriods := map[string][]float64{}
+	overall := make([]float64, trials)
+	for t := 0; t < trials; t++ {
+		done := simulateCompletion(order, history.samples, rng)
+		for epic, keys := range epicIssues {
+			latest := 0.0
+			for _, k := range keys {
+				latest = math.Max(latest, done[k])
+			}
+			epicPeriods[epic] = append(epicPeriods[epic], latest)
+			overall[t] = math.Max(overall[t], latest)
+		}
+	}
+
+	toDates := func(periods []float64) forecastDates {
+		sort.Float64s(periods)
+		at := func(p float64) time.Time {
+			return now.Add(time.Duration(percentile(periods, p) * float64(history.period)))
+		}
+		return forecastDates{P50: at(0.5), P85: at(0.85), P95: at(0.95)}
+	}
+
+	result := forecast{
+		Trials:     trials,
+		Samples:    history.samples,
+		PeriodDays: history.period.Hours() / 24,
+		Remaining:  remaining,
+		Epics:      map[string]forecastDates{},
+		Overall:    toDates(overall),
+	}
+	for epic, periods := range epicPeriods {
+		result.Epics[epic] = toDates(periods)
+	}
+	return result, nil
+}
+
+// graphHistory derives a forecast history from the issues of the graph being forecast
+func graphHistory(issues []issue, method string, n int, now time.Time) (forecastHistory, error) {
+	switch method {
+	case forecastThroughput:
+		return throughputHistory(issues, n, now), nil
+	case forecastVelocity:
+		return sprintHistory(issues, n), nil
+	}
+	return forecastHistory{}, fmt.Errorf("unknown forecast method %q", method)
+}
@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_runForecast(t *testing.T) {
+	now := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+	issues := []issue{
+		{Key: "JG-1", EpicKey: "JG-10", Status: "Backlog", Estimate: 5},
+		{Key: "JG-2", EpicKey: "JG-10", Status: "Backlog", Estimate: 5, blockedByKeys: []string{"JG-1"}},
+		{Key: "JG-3", EpicKey: "JG-20", Status: "Backlog", Estimate: 10},
+		{Key: "JG-4", EpicKey: "JG-20", Status: "Closed", Estimate: 100},
+	}
+
+	t.Run("constant capacity is deterministic", func(t *testing.T) {
+		history := forecastHistory{samples: []float64{5}, period: week}
+		f, err := runForecast(issues, history, 10, now, rand.New(rand.NewSource(1)))
+		assert.NoError(t, err)
+		assert.Equal(t, 20.0, f.Remaining)
+		assert.Equal(t, now.Add(2*week), f.Epics["JG-10"].P95)
+		assert.Equal(t, now.Add(4*week), f.Epics["JG-20"].P50)
+		assert.Equal(t, now.Add(4*week), f.Overall.P85)
+	})
+
+	t.Run("three-point estimates are weighed by their PERT estimate", func(t *testing.T) {
+		history := forecastHistory{samples: []float64{5}, period: week}
+		pert := []issue{{Key: "JG-1", EpicKey: "JG-10", Status: "Backlog", Estimate: 1, ThreePoint: &threePointEstimate{Optimistic: 4, Likely: 9, Pessimistic: 20}}}
+		f, err := runForecast(pert, history, 10, now, rand.New(rand.NewSource(1)))
+		assert.NoError(t, err)
+		assert.Equal(t, 10.0, f.Remaining)
+		assert.Equal(t, now.Add(2*week), f.Overall.P50)
+	})
+
+	t.Run("blockers are worked first", func(t *testing.T) {
+		order := topologicalOrder([]issue{
+			{Key: "JG-1", blockedByKeys: []string{"JG-2"}},
+			{Key: "JG-2"},
+		})
+		assert.Equal(t, "JG-2", order[0].Key)
+	})
+
+	t.Run("no history", func(t *testing.T) {
+		history := forecastHistory{samples: []float64{0, 0}, period: week}
+		_, err := runForecast(issues, history, 10, now, rand.New(rand.NewSource(1)))
+		assert.Error(t, err)
+	})
+}
+
+func Test_throughputHistory(t *testing.T) {
+	now := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	issues := []issue{
+		{Key: "JG-1", Status: "Closed", Estimate: 3, Resolved: now.Add(-24 * time.Hour)},
+		{Key: "JG-2", Status: "Closed", Estimate: 2, Resolved: now.Add(-10 * 24 * time.Hour)},
+		{Key: "JG-3", Status: "Closed", Estimate: 8, Resolved: now.Add(-100 * 24 * time.Hour)},
+		{Key: "JG-4", Status: "In Progress", Estimate: 5},
+	}
+	h := throughputHistory(issues, 3, now)
+	assert.Equal(t, []float64{0, 2, 3}, h.samples)
+}
@@ -6,6 +6,7 @@ require (
 	github.com/gin-gonic/gin v1.8.2
 	github.com/stretchr/testify v1.8.1
 	github.com/tidwall/gjson v1.14.4
+	golang.org/x/term v0.3.0
 )
 
 require (
@@ -27,6 +27,51 @@ func issuesToBlocksGraph(issues []issue) map[string][]string {
 	return blocksGraph
 }
 
+// upstreamKeys returns key and every issue that transitively blocks it
+func upstreamKeys(issues []issue, key string) map[string]struct{} {
+	byKey := make(map[string]issue, len(issues))
+	for _, iss := range issues {
+		byKey[iss.Key] = iss
+	}
+
+	result := map[string]struct{}{}
+	var visit func(k string)
+	visit = func(k string) {
+		if _, seen := result[k]; seen {
+			return
+		}
+		result[k] = struct{}{}
+		for _, blockedBy := range byKey[k].blockedByKeys {
+			visit(blockedBy)
+		}
+	}
+	visit(key)
+	return result
+}
+
+// filterGraph returns the subgraph of resp made of the given issue keys
+func filterGraph(resp graphResponse, keep map[string]struct{}) graphResponse {
+	issues := []issue{}
+	for _, iss := range resp.Issues {
+		if _, ok := keep[iss.Key]; ok {
+			issues = append(issues, iss)
+		}
+	}
+	filtered := map[string][]string{}
+	for blocker, blocked := range resp.Graph {
+		if _, ok := keep[blocker]; !ok {
+			continue
+		}
+		filtered[blocker] = []string{}
+		for _, b := range blocked {
+			if _, ok := keep[b]; ok {
+				filtered[blocker] = append(filtered[blocker], b)
+			}
+		}
+	}
+	return graphResponse{Issues: issues, Graph: filtered}
+}
+
 type errBadStatus struct {
 	statusCode int
 }
@@ -47,31 +92,45 @@ func getSingleIssue(jc jiraClient, key string) (issue, error) {
 	return issues[0], nil
 }
 
-func getEpicInfos(jc jiraClient, keys []string) map[string]epicInfo {
+// getEpicInfos looks up the name and color of each epic, failing if any lookup fails. Issues without an epic have an
+// empty key, which is skipped.
+func getEpicInfos(jc jiraClient, keys []string) (map[string]epicInfo, error) {
 	type singleEpicResult struct {
 		key  string
 		info epicInfo
+		err  error
 	}
 	ch := make(chan singleEpicResult)
 
+	lookups := 0
 	for _, key := range keys {
+		if len(key) == 0 {
+			continue
+		}
+		lookups++
 		go func(key string) {
 			info, err := getEpicInfo(jc, key)
-			if err != nil {
-				log.Printf("failed to get epic info: %v", err)
-				ch <- singleEpicResult{key: key}
-				return
-			}
-			ch <- singleEpicResult{key: key, info: info}
+			ch <- singleEpicResult{key: key, info: info, err: err}
 		}(key)
 	}
 
 	result := map[string]epicInfo{}
-	for _ = range keys {
+	var firstErr error
+	for i := 0; i < lookups; i++ {
 		r := <-ch
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to get epic info of %s: %w", r.key, r.err)
+			}
+			continue
+		}
 		result[r.key] = r.info
+		jc.report(loadProgress{Type: progressEpic, Key: r.key, Done: i + 1, Total: lookups})
 	}
-	return result
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return result, nil
 }
 
 type epicInfo struct {
@@ -85,6 +144,9 @@ func getEpicInfo(jc jiraClient, key string) (epicInfo, error) {
 		return epicInfo{}, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return epicInfo{}, errBadStatus{resp.StatusCode}
+	}
 
 	resultBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
@@ -101,52 +163,130 @@ func getIssues(jc jiraClient, epicKeys ...string) ([]issue, error) {
 	if len(epicKeys) == 0 {
 		return nil, errors.New("at least one epic key is required")
 	}
-	jql := fmt.Sprintf(`"%s" IN (%s)`, jc.fieldConfig.EpicLink, strings.Join(epicKeys, ","))
-	return getIssuesJQL(jc, jql)
+	return getIssuesJQL(jc, epicsJQL(jc, epicKeys))
 }
 
-func getMilestoneEpics(jc jiraClient, milestoneKey string) ([]issue, error) {
-	jql := fmt.Sprintf(`issue IN linkedIssues("%s") AND type=epic`, milestoneKey)
-	return getIssuesJQL(jc, jql)
+func epicsJQL(jc jiraClient, epicKeys []string) string {
+	return fmt.Sprintf(`"%s" IN (%s)`, jc.fieldConfig.EpicLink, strings.Join(epicKeys, ","))
 }
 
-func getIssuesJQL(jc jiraClient, jql string) ([]issue, error) {
-	result := []issue{}
-	epicKeys := map[string]struct{}{}
+func getEpicGraph(jc jiraClient, epicKey string) (graphResponse, error) {
+	issues, err := getIssues(jc, epicKey)
+	if err != nil {
+		return graphResponse{}, err
+	}
+	return newGraphResponse(issues)
+}
 
-	for {
-		b, err := jc.Search(jql, jc.getRequestFields(), len(result))
-		if err != nil {
-			return nil, err
-		}
-		parsed := gjson.ParseBytes(b)
+func getMilestoneGraph(jc jiraClient, milestoneKey string) (graphResponse, error) {
+	// the epics and their own epics aren't part of the milestone's graph, so loading them isn't reported as progress
+	epicsClient := jc
+	epicsClient.progress = nil
+	epics, err := getMilestoneEpics(epicsClient, milestoneKey)
+	if err != nil {
+		return graphResponse{}, err
+	}
+	return getEpicsGraph(jc, epics)
+}
 
-		for _, parsedIssue := range parsed.Get("issues").Array() {
-			iss := jc.unmarshallIssue(parsedIssue)
+func getEpicsGraph(jc jiraClient, epics []issue) (graphResponse, error) {
+	if len(epics) == 0 {
+		return graphResponse{}, errBadStatus{http.StatusNotFound}
+	}
 
-			parsedBlocks := parsedIssue.Get(`fields.issuelinks.#[type.name=="Blocks"]#.inwardIssue.key`).Array()
-			iss.blockedByKeys = make([]string, len(parsedBlocks))
-			for i := range parsedBlocks {
-				iss.blockedByKeys[i] = parsedBlocks[i].String()
-			}
+	epicKeys := make([]string, len(epics))
+	for i := range epics {
+		epicKeys[i] = epics[i].Key
+	}
 
-			result = append(result, iss)
-			epicKeys[iss.EpicKey] = struct{}{}
-		}
+	issues, err := getIssues(jc, epicKeys...)
+	if err != nil {
+		return graphResponse{}, err
+	}
+	return newGraphResponse(issues)
+}
 
-		total := parsed.Get("total").Int()
-		if len(result) >= int(total) {
-			break
-		}
+// graphLoader loads the graph of an epic or a milestone. Any key can be loaded either way, so cacheKind keeps the two
+// apart in the response cache and the history store.
+type graphLoader struct {
+	cacheKind string
+	load      func(jiraClient, string) (graphResponse, error)
+}
+
+var (
+	epicGraphs      = graphLoader{cacheKind: "epic-graphs", load: getEpicGraph}
+	milestoneGraphs = graphLoader{cacheKind: "milestone-graphs", load: getMilestoneGraph}
+	graphLoaders    = []graphLoader{epicGraphs, milestoneGraphs}
+)
+
+// keyGraphLoader resolves key to the milestone or the epic graph loader depending on its issue type
+func keyGraphLoader(jc jiraClient, key string) (graphLoader, error) {
+	iss, err := getSingleIssue(jc, key)
+	if err != nil {
+		return graphLoader{}, err
+	}
+	if iss.Type == "Milestone" {
+		return milestoneGraphs, nil
+	}
+	return epicGraphs, nil
+}
+
+// getKeyGraph resolves key to a milestone or an epic graph depending on its issue type
+func getKeyGraph(jc jiraClient, key string) (graphResponse, error) {
+	loader, err := keyGraphLoader(jc, key)
+	if err != nil {
+		return graphResponse{}, err
+	}
+	return loader.load(jc, key)
+}
+
+func getJQLGraph(jc jiraClient, jql string) (graphResponse, error) {
+	issues, err := getIssuesJQL(jc, jql)
+	if err != nil {
+		return graphResponse{}, err
+	}
+	return newGraphResponse(issues)
+}
+
+func newGraphResponse(issues []issue) (graphResponse, error) {
+	if len(issues) == 0 {
+		return graphResponse{}, errBadStatus{http.StatusNotFound}
+	}
+	return graphResponse{
+		Issues: issues,
+		Graph:  issuesToBlocksGraph(issues),
+	}, nil
+}
+
+func getMilestoneEpics(jc jiraClient, milestoneKey string) ([]issue, error) {
+	jql := fmt.Sprintf(`issue IN linkedIssues("%s") AND type=epic`, milestoneKey)
+	return getIssuesJQL(jc, jql)
+}
+
+func getIssuesJQL(jc jiraClient, jql string) ([]issue, error) {
+	search := searchIssues
+	if !jc.asOf.IsZero() {
+		search = searchIssuesAsOf
+	}
+	result, err := search(jc, jql)
+	if err != nil {
+		return nil, err
 	}
 
+	epicKeys := map[string]struct{}{}
+	for _, iss := range result {
+		epicKeys[iss.EpicKey] = struct{}{}
+	}
 	dedupedEpicKeys := make([]string, 0, len(epicKeys))
 	for k := range epicKeys {
 		dedupedEpicKeys = append(dedupedEpicKeys, k)
 	}
 	log.Printf("JQL %s returned %s", jql, dedupedEpicKeys)
 
-	epicToInfo := getEpicInfos(jc, dedupedEpicKeys)
+	epicToInfo, err := getEpicInfos(jc, dedupedEpicKeys)
+	if err != nil {
+		return nil, err
+	}
 	for i := range result {
 		info := epicToInfo[result[i].EpicKey]
 		result[i].Color = info.color
@@ -155,3 +295,40 @@ func getIssuesJQL(jc jiraClient, jql string) ([]issue, error) {
 
 	return result, nil
 }
+
+// searchIssues pages through every issue matching jql, without resolving epic names and colors
+func searchIssues(jc jiraClient, jql string) ([]issue, error) {
+	result := []issue{}
+	err := searchPages(jc, jql, jc.getRequestFields(), nil, func(page gjson.Result) {
+		batch := []issue{}
+		for _, parsedIssue := range page.Get("issues").Array() {
+			batch = append(batch, jc.unmarshallLinkedIssue(parsedIssue))
+		}
+		jc.report(loadProgress{Type: progressIssues, JQL: jql, Issues: batch})
+		result = append(result, batch...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// searchPages runs a search to completion, handing each page of results to onPage
+func searchPages(jc jiraClient, jql string, fields, expand []string, onPage func(page gjson.Result)) error {
+	seen := 0
+	for {
+		b, err := jc.Search(jql, fields, seen, expand...)
+		if err != nil {
+			return err
+		}
+		parsed := gjson.ParseBytes(b)
+		onPage(parsed)
+
+		count := len(parsed.Get("issues").Array())
+		seen += count
+		jc.report(loadProgress{Type: progressPage, JQL: jql, Done: seen, Total: int(parsed.Get("total").Int())})
+		if count == 0 || seen >= int(parsed.Get("total").Int()) {
+			return nil
+		}
+	}
+}
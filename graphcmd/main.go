@@ -1,9 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"flag"
+	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	graph "github.com/andrei-m/jira-graph"
 )
@@ -15,6 +20,24 @@ var (
 	flaggedField         = flag.String("flagged-field", "customfield_10002", "the name of the custom field for impediment flagging")
 	sprintsField         = flag.String("sprints-field", "Sprint", "the name of the custom field for Greenhopper sprints")
 	epicLinkField        = flag.String("epic-link-field", "Epic Link", "the name of the custom field for Epic Link")
+	optimisticField      = flag.String("optimistic-estimate-field", "", "the name of the custom field for optimistic three-point estimates (optional)")
+	likelyField          = flag.String("likely-estimate-field", "", "the name of the custom field for most likely three-point estimates (optional)")
+	pessimisticField     = flag.String("pessimistic-estimate-field", "", "the name of the custom field for pessimistic three-point estimates (optional)")
+	estimateMode         = flag.String("estimate-mode", graph.EstimatePoints, "what estimates measure: 'points' from -estimate-field, 'hours' remaining from time tracking, or 'original' time tracking estimates in hours")
+	hoursPerPoint        = flag.Float64("hours-per-point", 0, "converts between time tracking hours and points, e.g. for a time tracking -initial-estimate-field (optional)")
+	statusCategories     = flag.String("status-categories", "", "semicolon-separated Status=Category pairs mapping workflow statuses to Backlog, In Progress or Closed, e.g. 'To Do=Backlog;Done=Closed' (defaults to the built-in workflow)")
+	historyDir           = flag.String("history-dir", "", "a directory to store graph snapshots in, enabling '?at=' queries (optional)")
+	watch                = flag.String("watch", "", "comma-separated epic or milestone keys to snapshot into -history-dir")
+	historyInterval      = flag.Duration("history-interval", time.Hour, "how often to snapshot the -watch keys")
+	historyRetention     = flag.Duration("history-retention", 0, "remove snapshots older than this, e.g. 2160h for 90 days (keeps them forever by default)")
+	cacheDir             = flag.String("cache-dir", "", "a directory to cache Jira responses in, served while Jira is unavailable (optional)")
+	cacheMaxAge          = flag.Duration("cache-max-age", 0, "serve cached responses younger than this without asking Jira, e.g. when webhooks keep the cache fresh")
+	webhookSecret        = flag.String("webhook-secret", "", "the shared secret of Jira webhooks sent to /webhooks/jira (optional)")
+	adminToken           = flag.String("admin-token", "", "the bearer token required by /api/admin endpoints, which are disabled without it (optional)")
+	prefetch             = flag.String("prefetch", "", "comma-separated epic or milestone keys whose graphs are kept warm in -cache-dir (optional)")
+	prefetchInterval     = flag.Duration("prefetch-interval", 15*time.Minute, "how often to refresh the -prefetch graphs")
+	prefetchJitter       = flag.Duration("prefetch-jitter", time.Minute, "a random delay of up to this much added to each -prefetch refresh, spreading them out")
+	requestsPerMinute    = flag.Int("jira-requests-per-minute", 0, "the most requests to send to Jira per minute, shared by all requests and prefetching (optional)")
 )
 
 func main() {
@@ -28,19 +51,186 @@ func main() {
 	}
 
 	flag.Parse()
+
+	switch flag.Arg(0) {
+	case "":
+		fc := fieldConfig()
+		opts := graph.ServerOptions{
+			HistoryDir:            *historyDir,
+			HistoryInterval:       *historyInterval,
+			HistoryRetention:      *historyRetention,
+			CacheDir:              *cacheDir,
+			CacheMaxAge:           *cacheMaxAge,
+			WebhookSecret:         *webhookSecret,
+			PrefetchInterval:      *prefetchInterval,
+			PrefetchJitter:        *prefetchJitter,
+			JiraRequestsPerMinute: *requestsPerMinute,
+			AdminToken:            *adminToken,
+		}
+		if len(*watch) > 0 {
+			if len(*historyDir) == 0 {
+				log.Fatal("-watch requires -history-dir")
+			}
+			opts.Watch = strings.Split(*watch, ",")
+		}
+		if len(*prefetch) > 0 {
+			if len(*cacheDir) == 0 {
+				log.Fatal("-prefetch requires -cache-dir")
+			}
+			opts.Prefetch = strings.Split(*prefetch, ",")
+		}
+		if err := graph.StartServer(user, pass, *jiraHost, fc, opts); err != nil {
+			log.Fatalf("server failed with error: %v", err)
+		}
+	case "export":
+		runExport(user, pass, flag.Args()[1:])
+	case "show":
+		runShow(user, pass, flag.Args()[1:])
+	case "tui":
+		runTUI(user, pass, flag.Args()[1:])
+	case "snapshot":
+		runSnapshot(user, pass, flag.Args()[1:])
+	case "report":
+		runReport(user, pass, flag.Args()[1:])
+	default:
+		log.Fatalf("unknown command %q", flag.Arg(0))
+	}
+}
+
+// newSubcommand returns a FlagSet that also accepts the top-level Jira flags, so they may be passed before or after the subcommand name
+func newSubcommand(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	flag.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	return fs
+}
+
+func fieldConfig() graph.FieldConfig {
 	if len(*jiraHost) == 0 {
 		log.Fatal("-jira-host flag is required")
 	}
-
 	fc := graph.FieldConfig{
 		InitialEstimate: *initialEstimateField,
 		Estimate:        *estimateField,
 		Flagged:         *flaggedField,
 		Sprints:         *sprintsField,
 		EpicLink:        *epicLinkField,
+
+		OptimisticEstimate:  *optimisticField,
+		LikelyEstimate:      *likelyField,
+		PessimisticEstimate: *pessimisticField,
+
+		EstimateMode:  *estimateMode,
+		HoursPerPoint: *hoursPerPoint,
+	}
+	if len(*statusCategories) > 0 {
+		categories, err := graph.ParseStatusCategories(*statusCategories)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fc.StatusCategories = categories
+	}
+	if err := fc.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	return fc
+}
+
+// queryFlags registers the flags that select which issues to graph
+func queryFlags(fs *flag.FlagSet) *graph.GraphQuery {
+	q := &graph.GraphQuery{}
+	fs.StringVar(&q.EpicKey, "epic", "", "the key of the epic to graph")
+	fs.StringVar(&q.MilestoneKey, "milestone", "", "the key of the milestone to graph")
+	fs.StringVar(&q.JQL, "jql", "", "arbitrary JQL selecting the issues to graph")
+	return q
+}
+
+func runExport(user, pass string, args []string) {
+	fs := newSubcommand("export")
+	q := queryFlags(fs)
+	format := fs.String("format", "json", fmt.Sprintf("the output format: %s", strings.Join(graph.ExportFormats(), ", ")))
+	out := fs.String("o", "", "the file to write to; stdout if empty")
+	fs.Parse(args)
+	fc := fieldConfig()
+
+	var buf bytes.Buffer
+	if err := graph.Export(user, pass, *jiraHost, fc, *q, *format, &buf); err != nil {
+		log.Fatalf("export failed: %v", err)
+	}
+	writeOutput(*out, buf.Bytes())
+}
+
+func runShow(user, pass string, args []string) {
+	fs := newSubcommand("show")
+	opts := graph.ShowOptions{}
+	fs.StringVar(&opts.Upstream, "upstream", "", "only show this issue and the issues upstream of it")
+	fs.BoolVar(&opts.ASCII, "ascii", false, "use ASCII instead of Unicode glyphs")
+	key := parseWithKey(fs, args)
+	fc := fieldConfig()
+
+	if err := graph.Show(user, pass, *jiraHost, fc, key, opts, os.Stdout); err != nil {
+		log.Fatalf("show failed: %v", err)
+	}
+}
+
+func runTUI(user, pass string, args []string) {
+	fs := newSubcommand("tui")
+	key := parseWithKey(fs, args)
+	fc := fieldConfig()
+
+	if err := graph.RunTUI(user, pass, *jiraHost, fc, key); err != nil {
+		log.Fatalf("tui failed: %v", err)
 	}
+}
+
+func runSnapshot(user, pass string, args []string) {
+	fs := newSubcommand("snapshot")
+	opts := graph.SnapshotOptions{}
+	fs.BoolVar(&opts.Redact, "redact", false, "blank out issue summaries and replace assignee, epic, label, fix version and sprint names with placeholders")
+	out := fs.String("o", "", "the file to write to; stdout if empty")
+	key := parseWithKey(fs, args)
+	fc := fieldConfig()
 
-	if err := graph.StartServer(user, pass, *jiraHost, fc); err != nil {
-		log.Fatalf("server failed with error: %v", err)
+	var buf bytes.Buffer
+	if err := graph.Snapshot(user, pass, *jiraHost, fc, key, opts, &buf); err != nil {
+		log.Fatalf("snapshot failed: %v", err)
+	}
+	writeOutput(*out, buf.Bytes())
+}
+
+func runReport(user, pass string, args []string) {
+	fs := newSubcommand("report")
+	out := fs.String("o", "", "the file to write to; stdout if empty")
+	key := parseWithKey(fs, args)
+	fc := fieldConfig()
+
+	var buf bytes.Buffer
+	if err := graph.Report(user, pass, *jiraHost, fc, key, &buf); err != nil {
+		log.Fatalf("report failed: %v", err)
+	}
+	writeOutput(*out, buf.Bytes())
+}
+
+// parseWithKey parses a subcommand's flags around a single required positional epic or milestone key
+func parseWithKey(fs *flag.FlagSet, args []string) string {
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		log.Fatalf("usage: graphcmd %s [flags] EPIC-OR-MILESTONE-KEY", fs.Name())
+	}
+	key := fs.Arg(0)
+	fs.Parse(fs.Args()[1:])
+	return key
+}
+
+func writeOutput(path string, b []byte) {
+	if len(path) == 0 {
+		if _, err := os.Stdout.Write(b); err != nil {
+			log.Fatalf("failed to write output: %v", err)
+		}
+		return
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		log.Fatalf("failed to write %s: %v", path, err)
 	}
 }
@@ -0,0 +1,205 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const historyTimeLayout = "20060102T150405Z"
+
+// issueKeyPattern guards file names made from request parameters against anything that isn't a Jira issue key
+var issueKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_]+-[0-9]+$`)
+
+// historyStore keeps graph snapshots on disk as one JSON file per kind of graph, key and capture time, i.e.
+// '<dir>/<kind>/<key>/<time>.json'
+type historyStore struct {
+	dir string
+}
+
+func (h historyStore) keyDir(kind, key string) (string, error) {
+	if !issueKeyPattern.MatchString(key) {
+		return "", errInvalidQuery{fmt.Sprintf("%q is not an issue key", key)}
+	}
+	return filepath.Join(h.dir, kind, strings.ToUpper(key)), nil
+}
+
+func (h historyStore) save(kind, key string, at time.Time, resp graphResponse) error {
+	dir, err := h.keyDir(kind, key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	// write then rename, so that readers never see a partial snapshot
+	path := filepath.Join(dir, at.UTC().Format(historyTimeLayout)+".json")
+	if err := os.WriteFile(path+".tmp", b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// times lists when a key's snapshots were captured, oldest first
+func (h historyStore) times(kind, key string) ([]time.Time, error) {
+	dir, err := h.keyDir(kind, key)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []time.Time{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	result := []time.Time{}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		t, err := time.Parse(historyTimeLayout, strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue
+		}
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result, nil
+}
+
+func (h historyStore) load(kind, key string, at time.Time) (graphResponse, error) {
+	dir, err := h.keyDir(kind, key)
+	if err != nil {
+		return graphResponse{}, err
+	}
+	b, err := os.ReadFile(filepath.Join(dir, at.UTC().Format(historyTimeLayout)+".json"))
+	if err != nil {
+		return graphResponse{}, err
+	}
+	var resp graphResponse
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return graphResponse{}, err
+	}
+	resp.SnapshotAt = &at
+	return resp, nil
+}
+
+// prune removes the key's snapshots captured before t
+func (h historyStore) prune(kind, key string, t time.Time) error {
+	times, err := h.times(kind, key)
+	if err != nil {
+		return err
+	}
+	dir, err := h.keyDir(kind, key)
+	if err != nil {
+		return err
+	}
+	for _, at := range times {
+		if !at.Before(t) {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, at.UTC().Format(historyTimeLayout)+".json")); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// closest loads the key's snapshot captured closest to t, before or after
+func (h historyStore) closest(kind, key string, t time.Time) (graphResponse, error) {
+	times, err := h.times(kind, key)
+	if err != nil {
+		return graphResponse{}, err
+	}
+	if len(times) == 0 {
+		return graphResponse{}, errBadStatus{http.StatusNotFound}
+	}
+
+	best := times[0]
+	for _, candidate := range times[1:] {
+		if absDuration(candidate.Sub(t)) < absDuration(best.Sub(t)) {
+			best = candidate
+		}
+	}
+	return h.load(kind, key, best)
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// recordHistory snapshots the graph of every watched epic or milestone right away, and then every interval. Snapshots
+// older than retention are removed, unless retention is zero.
+func recordHistory(jc jiraClient, store historyStore, keys []string, interval, retention time.Duration) {
+	record := func() {
+		for _, key := range keys {
+			now := time.Now()
+			var resp graphResponse
+			loader, err := keyGraphLoader(jc, key)
+			if err == nil {
+				resp, err = loader.load(jc, key)
+			}
+			if err == nil {
+				err = store.save(loader.cacheKind, key, now, resp)
+			}
+			if err == nil && retention > 0 {
+				err = store.prune(loader.cacheKind, key, now.Add(-retention))
+			}
+			if err != nil {
+				log.Printf("failed to record the graph of %s: %v", key, err)
+			}
+		}
+	}
+
+	record()
+	for range time.Tick(interval) {
+		record()
+	}
+}
+
+// UnmarshalJSON restores the issues' blockers, which aren't serialized, from the blocks graph
+func (resp *graphResponse) UnmarshalJSON(b []byte) error {
+	type plainGraphResponse graphResponse
+	var plain plainGraphResponse
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*resp = graphResponse(plain)
+
+	blockers := map[string][]string{}
+	for _, e := range sortedEdges(resp.Graph) {
+		blockers[e[1]] = append(blockers[e[1]], e[0])
+	}
+	for i := range resp.Issues {
+		resp.Issues[i].blockedByKeys = blockers[resp.Issues[i].Key]
+	}
+	return nil
+}
+
+// parseAt reads a point in time given as a date or an RFC 3339 timestamp
+func parseAt(raw string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, errInvalidQuery{"at must be a date (2006-01-02) or an RFC 3339 timestamp"}
+	}
+	return t, nil
+}
@@ -0,0 +1,39 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_historyStore(t *testing.T) {
+	store := historyStore{dir: t.TempDir()}
+	day := func(d int) time.Time { return time.Date(2026, 9, d, 9, 0, 0, 0, time.UTC) }
+
+	_, err := store.closest(milestoneGraphs.cacheKind, "JG-1", day(1))
+	assert.Equal(t, errBadStatus{404}, err)
+
+	for _, d := range []int{1, 8, 15} {
+		issues := []issue{{Key: "JG-2", Estimate: float64(d), blockedByKeys: []string{"JG-3"}}}
+		assert.NoError(t, store.save(milestoneGraphs.cacheKind, "JG-1", day(d), graphResponse{Issues: issues, Graph: issuesToBlocksGraph(issues)}))
+	}
+
+	resp, err := store.closest(milestoneGraphs.cacheKind, "JG-1", day(10))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 8.0, resp.Issues[0].Estimate)
+		assert.Equal(t, []string{"JG-3"}, resp.Issues[0].blockedByKeys)
+		assert.True(t, day(8).Equal(*resp.SnapshotAt))
+	}
+	// the epic graph of a milestone key is a different graph
+	_, err = store.closest(epicGraphs.cacheKind, "JG-1", day(10))
+	assert.Equal(t, errBadStatus{404}, err)
+
+	_, err = store.closest(milestoneGraphs.cacheKind, "../JG-1", day(10))
+	assert.IsType(t, errInvalidQuery{}, err)
+
+	assert.NoError(t, store.prune(milestoneGraphs.cacheKind, "JG-1", day(8)))
+	times, err := store.times(milestoneGraphs.cacheKind, "JG-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{day(8), day(15)}, times)
+}
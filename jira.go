@@ -1,6 +1,7 @@
 package graph
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -16,23 +17,31 @@ import (
 )
 
 type issue struct {
-	Key              string   `json:"key"`
-	Type             string   `json:"type"`
-	TypeImageURL     string   `json:"typeImageURL"`
-	Summary          string   `json:"summary"`
-	Status           string   `json:"status"`
-	Assignee         string   `json:"assignee"`
-	AssigneeImageURL string   `json:"assigneeImageURL"`
-	InitialEstimate  float64  `json:"initialEstimate"`
-	Estimate         float64  `json:"estimate"` // note that this doesn't differentiate between '0' and unset
-	Priority         string   `json:"priority"`
-	PriorityImageURL string   `json:"priorityImageURL"`
-	Labels           []string `json:"labels"`
-	Flagged          bool     `json:"flagged"`
-	Sprints          []sprint `json:"sprints"`
-	Color            string   `json:"color"`
-	EpicKey          string   `json:"epicKey"`
-	EpicName         string   `json:"epicName"`
+	Key              string              `json:"key"`
+	Type             string              `json:"type"`
+	TypeImageURL     string              `json:"typeImageURL"`
+	Summary          string              `json:"summary"`
+	Status           string              `json:"status"`
+	Assignee         string              `json:"assignee"`
+	AssigneeImageURL string              `json:"assigneeImageURL"`
+	InitialEstimate  float64             `json:"initialEstimate"`
+	Estimate         float64             `json:"estimate"` // note that this doesn't differentiate between '0' and unset
+	Priority         string              `json:"priority"`
+	PriorityImageURL string              `json:"priorityImageURL"`
+	Labels           []string            `json:"labels"`
+	Flagged          bool                `json:"flagged"`
+	Sprints          []sprint            `json:"sprints"`
+	Color            string              `json:"color"`
+	EpicKey          string              `json:"epicKey"`
+	EpicName         string              `json:"epicName"`
+	Resolved         time.Time           `json:"resolved"`
+	ThreePoint       *threePointEstimate `json:"threePoint,omitempty"`
+	DueDate          time.Time           `json:"dueDate"`
+	FixVersions      []fixVersion        `json:"fixVersions"`
+	EstimateUnit     string              `json:"estimateUnit"`
+	RemainingHours   float64             `json:"remainingHours"`
+	LoggedHours      float64             `json:"loggedHours"`
+	OriginalHours    float64             `json:"originalHours"`
 	blockedByKeys    []string
 }
 
@@ -41,6 +50,32 @@ type jiraClient struct {
 	user        string
 	pass        string
 	fieldConfig FieldConfig
+	// httpClient sends the requests to Jira; nil uses a default client
+	httpClient *http.Client
+	// ctx, when set, is the context of the server request that Jira is asked on behalf of. Its requests are abandoned,
+	// or not sent at all, once that request is cancelled.
+	ctx context.Context
+	// asOf, when set, rewinds searched issues to how they were at that time using their changelogs
+	asOf time.Time
+	// progress, when set, is told about every step of loading a graph
+	progress func(loadProgress)
+	// budget, when set, limits the requests sent to Jira
+	budget *requestBudget
+}
+
+func (j jiraClient) context() context.Context {
+	if j.ctx == nil {
+		return context.Background()
+	}
+	return j.ctx
+}
+
+// detached drops the context of a finished request, for lookups that outlive it such as cache revalidation
+func (j jiraClient) detached() jiraClient {
+	if j.ctx != nil && j.ctx.Err() != nil {
+		j.ctx = nil
+	}
+	return j
 }
 
 func (j jiraClient) Get(path string, q url.Values) (*http.Response, error) {
@@ -49,47 +84,73 @@ func (j jiraClient) Get(path string, q url.Values) (*http.Response, error) {
 		Host:   j.host,
 		Path:   path,
 	}
-	req, err := http.NewRequest("GET", baseURL.String(), nil)
+	req, err := http.NewRequestWithContext(j.context(), "GET", baseURL.String(), nil)
 	if err != nil {
 		return nil, err
 	}
+	if j.budget != nil {
+		if err := j.budget.wait(j.context()); err != nil {
+			return nil, err
+		}
+	}
 	req.SetBasicAuth(j.user, j.pass)
 	req.URL.RawQuery = q.Encode()
 
-	client := &http.Client{}
+	client := j.httpClient
+	if client == nil {
+		client = &http.Client{}
+	}
 	return client.Do(req)
 }
 
-func (j jiraClient) Search(jql string, fields []string, startAt int) ([]byte, error) {
+func (j jiraClient) Search(jql string, fields []string, startAt int, expand ...string) ([]byte, error) {
 	q := url.Values{
 		"jql":     []string{jql},
 		"fields":  fields,
 		"startAt": []string{strconv.Itoa(startAt)},
 	}
+	if len(expand) > 0 {
+		q.Set("expand", strings.Join(expand, ","))
+	}
 	resp, err := j.Get("/rest/api/2/search", q)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, errBadStatus{resp.StatusCode}
+	}
 	return ioutil.ReadAll(resp.Body)
 }
 
 func (j jiraClient) getRequestFields() []string {
-	return []string{
+	fields := []string{
 		"assignee",
+		"duedate",
+		"fixVersions",
 		"issuelinks",
 		"issuetype",
 		"labels",
 		"priority",
+		"resolutiondate",
 		"status",
 		"summary",
+		"timeestimate",
+		"timeoriginalestimate",
+		"timespent",
 		j.fieldConfig.InitialEstimate,
 		j.fieldConfig.Estimate,
 		j.fieldConfig.Flagged,
 		j.fieldConfig.Sprints,
 		j.fieldConfig.EpicLink,
 	}
+	for _, f := range []string{j.fieldConfig.OptimisticEstimate, j.fieldConfig.LikelyEstimate, j.fieldConfig.PessimisticEstimate} {
+		if len(f) > 0 {
+			fields = append(fields, f)
+		}
+	}
+	return fields
 }
 
 func (j jiraClient) unmarshallIssue(r gjson.Result) issue {
@@ -116,8 +177,13 @@ func (j jiraClient) unmarshallIssue(r gjson.Result) issue {
 	priorityName := priority.Get("name").String()
 	priorityImageURL := priority.Get("iconUrl").String()
 
-	initialEstimate := fields.Get(j.fieldConfig.InitialEstimate).Float()
-	estimate := fields.Get(j.fieldConfig.Estimate).Float()
+	fc := j.fieldConfig
+	initialEstimate := fc.toEstimateUnit(fc.InitialEstimate, fields.Get(fc.InitialEstimate).Float())
+	estimate := fc.toEstimateUnit(fc.estimateField(), fields.Get(fc.estimateField()).Float())
+	if fc.EstimateMode == EstimateHours && categorizeStatus(status) == statusClosed {
+		// the remaining estimate of finished work is usually zero, so its size is the time spent on it instead
+		estimate = fc.toEstimateUnit("timespent", fields.Get("timespent").Float()) + estimate
+	}
 
 	flaggedObj := fields.Get(j.fieldConfig.Flagged).Array()
 	//TODO: the 'Impediment' constant should be configurable alongside the field name
@@ -132,6 +198,22 @@ func (j jiraClient) unmarshallIssue(r gjson.Result) issue {
 	sprintsResults := fields.Get(j.fieldConfig.Sprints).Array()
 	sprints := parseSprints(sprintsResults)
 
+	resolved, err := parseJiraTime(fields.Get("resolutiondate").String())
+	if err != nil {
+		log.Printf("bad resolution date for %s: %v", key, err)
+	}
+
+	dueDate, err := parseJiraDay(fields.Get("duedate").String())
+	if err != nil {
+		log.Printf("bad due date for %s: %v", key, err)
+	}
+
+	rawVersions := fields.Get("fixVersions").Array()
+	fixVersions := make([]fixVersion, len(rawVersions))
+	for i := range rawVersions {
+		fixVersions[i] = parseFixVersion(rawVersions[i])
+	}
+
 	return issue{
 		Key:              key,
 		Type:             issueTypeName,
@@ -148,7 +230,26 @@ func (j jiraClient) unmarshallIssue(r gjson.Result) issue {
 		Flagged:          flagged,
 		Sprints:          sprints,
 		EpicKey:          epicKey,
+		Resolved:         resolved,
+		ThreePoint:       j.parseThreePoint(fields),
+		DueDate:          dueDate,
+		FixVersions:      fixVersions,
+		EstimateUnit:     fc.estimateUnit(),
+		RemainingHours:   fields.Get("timeestimate").Float() / secondsPerHour,
+		LoggedHours:      fields.Get("timespent").Float() / secondsPerHour,
+		OriginalHours:    fields.Get("timeoriginalestimate").Float() / secondsPerHour,
+	}
+}
+
+// unmarshallLinkedIssue is unmarshallIssue plus the keys of the issues blocking it
+func (j jiraClient) unmarshallLinkedIssue(r gjson.Result) issue {
+	iss := j.unmarshallIssue(r)
+	parsedBlocks := r.Get(`fields.issuelinks.#[type.name=="Blocks"]#.inwardIssue.key`).Array()
+	iss.blockedByKeys = make([]string, len(parsedBlocks))
+	for i := range parsedBlocks {
+		iss.blockedByKeys[i] = parsedBlocks[i].String()
 	}
+	return iss
 }
 
 func parseSprints(sprintsResults []gjson.Result) []sprint {
@@ -161,12 +262,19 @@ func parseSprints(sprintsResults []gjson.Result) []sprint {
 		}
 		sprints[i] = sprint
 	}
-	sort.Slice(sprints, func(i, j int) bool { return sprints[i].Sequence < sprints[j].Sequence })
+	// Cloud-JIRA sprints have no sequence, so fall back to the order they started in
+	sort.SliceStable(sprints, func(i, j int) bool {
+		if sprints[i].Sequence != sprints[j].Sequence {
+			return sprints[i].Sequence < sprints[j].Sequence
+		}
+		return sprints[i].StartDate.Before(sprints[j].StartDate)
+	})
 	return sprints
 }
 
 type sprint struct {
 	ID        int       `json:"id"`
+	BoardID   int       `json:"boardId"`
 	State     string    `json:"state"`
 	Name      string    `json:"name"`
 	StartDate time.Time `json:"startDate"`
@@ -210,6 +318,13 @@ func parseSprint(rawSprint string) (sprint, error) {
 	if err != nil {
 		return sprint{}, fmt.Errorf("malformed endDate %s in: %s", keyVals["endDate"], rawSprint)
 	}
+	boardID := 0
+	if rawBoardID, ok := keyVals["rapidViewId"]; ok {
+		boardID, err = strconv.Atoi(rawBoardID)
+		if err != nil {
+			return sprint{}, fmt.Errorf("malformed rapidViewId %s in: %s", rawBoardID, rawSprint)
+		}
+	}
 	sequence, err := strconv.Atoi(keyVals["sequence"])
 	if err != nil {
 		return sprint{}, fmt.Errorf("malformed sequence %s in: %s", keyVals["sequence"], rawSprint)
@@ -217,6 +332,7 @@ func parseSprint(rawSprint string) (sprint, error) {
 
 	return sprint{
 		ID:        id,
+		BoardID:   boardID,
 		State:     keyVals["state"],
 		Name:      keyVals["name"],
 		StartDate: startDate,
@@ -225,6 +341,31 @@ func parseSprint(rawSprint string) (sprint, error) {
 	}, nil
 }
 
+// parseJiraTime parses the timestamps of regular Jira fields, which unlike sprint dates have no colon in the zone
+// offset. An empty value is the zero time.
+func parseJiraTime(raw string) (time.Time, error) {
+	if len(raw) == 0 {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02T15:04:05.000-0700", raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed time %s", raw)
+	}
+	return t, nil
+}
+
+// parseJiraDay parses date-only fields such as due dates and release dates
+func parseJiraDay(raw string) (time.Time, error) {
+	if len(raw) == 0 {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed date %s", raw)
+	}
+	return t, nil
+}
+
 func parseDate(raw string) (time.Time, error) {
 	if raw == "<null>" {
 		return time.Time{}, nil
@@ -1,12 +1,72 @@
 package graph
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
 )
 
+var fakeFieldConfig = FieldConfig{Estimate: "customfield_10031", EpicLink: "customfield_10014"}
+
+// fakeJira answers searches and epic lookups like Jira does, from canned results
+type fakeJira struct {
+	// searches maps JQL to the JSON array of issues it finds; other JQL is answered with a 400
+	searches map[string]string
+	// epics maps epic keys to their names; other epics are answered with a 404
+	epics map[string]string
+	// pageSize, when set, splits search results into pages of this many issues
+	pageSize int
+}
+
+func (f fakeJira) client(t *testing.T) jiraClient {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := strings.TrimPrefix(r.URL.Path, "/rest/agile/1.0/epic/"); key != r.URL.Path {
+			name, ok := f.epics[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprintf(w, `{"name": %q, "color": {"key": "color_1"}}`, name)
+			return
+		}
+		raw, ok := f.searches[r.URL.Query().Get("jql")]
+		if r.URL.Path != "/rest/api/2/search" || !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		issues := gjson.Parse(raw).Array()
+		start, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+		end := len(issues)
+		if f.pageSize > 0 && start+f.pageSize < end {
+			end = start + f.pageSize
+		}
+		page := []string{}
+		for _, iss := range issues[start:end] {
+			page = append(page, iss.Raw)
+		}
+		fmt.Fprintf(w, `{"total": %d, "issues": [%s]}`, len(issues), strings.Join(page, ","))
+	}))
+	t.Cleanup(ts.Close)
+	return jiraClient{host: ts.Listener.Addr().String(), fieldConfig: fakeFieldConfig, httpClient: ts.Client()}
+}
+
+// fakeIssue is the JSON of an issue as Jira returns it from a search
+func fakeIssue(key, issueType, epicKey string, blockedBy ...string) string {
+	links := []string{}
+	for _, b := range blockedBy {
+		links = append(links, fmt.Sprintf(`{"type": {"name": "Blocks"}, "inwardIssue": {"key": %q}}`, b))
+	}
+	return fmt.Sprintf(`{"key": %q, "fields": {"issuetype": {"name": %q}, "status": {"name": "Backlog"}, "customfield_10014": %q, "issuelinks": [%s]}}`,
+		key, issueType, epicKey, strings.Join(links, ","))
+}
+
 func Test_parseSprint(t *testing.T) {
 	t.Run("good sprint", func(t *testing.T) {
 		raw := "com.atlassian.greenhopper.service.sprint.Sprint@6468cb83[id=287,rapidViewId=71,state=ACTIVE,name=2018.09.03,goal=,startDate=2018-08-21T15:13:59.909Z,endDate=2018-09-04T13:00:00.000Z,completeDate=<null>,sequence=281]"
@@ -14,6 +74,7 @@ func Test_parseSprint(t *testing.T) {
 		assert.NoError(t, err)
 		expected := sprint{
 			ID:        287,
+			BoardID:   71,
 			State:     "ACTIVE",
 			Name:      "2018.09.03",
 			StartDate: time.Date(2018, 8, 21, 15, 13, 59, int(909*time.Millisecond), time.UTC),
@@ -29,6 +90,7 @@ func Test_parseSprint(t *testing.T) {
 		assert.NoError(t, err)
 		expected := sprint{
 			ID:        80,
+			BoardID:   1,
 			State:     "closed",
 			Name:      "native JIRA sprint json",
 			StartDate: time.Date(2021, 10, 12, 15, 20, 44, int(479*time.Millisecond), time.UTC),
@@ -44,6 +106,7 @@ func Test_parseSprint(t *testing.T) {
 		assert.NoError(t, err)
 		expected := sprint{
 			ID:        288,
+			BoardID:   243,
 			State:     "FUTURE",
 			Name:      "Alf 9/17 planning",
 			StartDate: time.Time{},
@@ -53,3 +116,69 @@ func Test_parseSprint(t *testing.T) {
 		assert.Equal(t, expected, spr)
 	})
 }
+
+func Test_parseThreePoint(t *testing.T) {
+	fc := FieldConfig{OptimisticEstimate: "customfield_1", LikelyEstimate: "customfield_2", PessimisticEstimate: "customfield_3"}
+	fields := gjson.Parse(`{"customfield_1": 2, "customfield_2": 3, "customfield_3": 10}`)
+	assert.Equal(t, &threePointEstimate{Optimistic: 2, Likely: 3, Pessimistic: 10}, jiraClient{fieldConfig: fc}.parseThreePoint(fields))
+
+	t.Run("unconfigured", func(t *testing.T) {
+		assert.Nil(t, jiraClient{}.parseThreePoint(fields))
+	})
+
+	t.Run("missing field", func(t *testing.T) {
+		assert.Nil(t, jiraClient{fieldConfig: fc}.parseThreePoint(gjson.Parse(`{"customfield_1": 2, "customfield_3": 10}`)))
+	})
+
+	t.Run("non-numeric field", func(t *testing.T) {
+		assert.Nil(t, jiraClient{fieldConfig: fc}.parseThreePoint(gjson.Parse(`{"customfield_1": 2, "customfield_2": "3", "customfield_3": 10}`)))
+	})
+
+	t.Run("converted to the estimate unit", func(t *testing.T) {
+		hours := fc
+		hours.EstimateMode, hours.HoursPerPoint = EstimateHours, 4
+		assert.Equal(t, &threePointEstimate{Optimistic: 8, Likely: 12, Pessimistic: 40}, jiraClient{fieldConfig: hours}.parseThreePoint(fields))
+
+		timeTracking := FieldConfig{OptimisticEstimate: "timeoriginalestimate", LikelyEstimate: "timeestimate", PessimisticEstimate: "timespent", EstimateMode: EstimateHours}
+		seconds := gjson.Parse(`{"timeoriginalestimate": 3600, "timeestimate": 7200, "timespent": 18000}`)
+		assert.Equal(t, &threePointEstimate{Optimistic: 1, Likely: 2, Pessimistic: 5}, jiraClient{fieldConfig: timeTracking}.parseThreePoint(seconds))
+	})
+}
+
+func Test_unmarshallIssueEstimateModes(t *testing.T) {
+	raw := gjson.Parse(`{"key": "JG-1", "fields": {
+		"customfield_10031": 3,
+		"timeoriginalestimate": 36000,
+		"timeestimate": 14400,
+		"timespent": 21600
+	}}`)
+	fc := FieldConfig{InitialEstimate: "timeoriginalestimate", Estimate: "customfield_10031"}
+
+	tests := []struct {
+		mode            string
+		hoursPerPoint   float64
+		unit            string
+		estimate        float64
+		initialEstimate float64
+	}{
+		// a time-tracking initial estimate can't be converted to points, so it stays in seconds
+		{mode: "", unit: "points", estimate: 3, initialEstimate: 36000},
+		{mode: EstimatePoints, hoursPerPoint: 5, unit: "points", estimate: 3, initialEstimate: 2},
+		{mode: EstimateHours, unit: "hours", estimate: 4, initialEstimate: 10},
+		{mode: EstimateOriginal, unit: "hours", estimate: 10, initialEstimate: 10},
+	}
+	for _, tt := range tests {
+		fc.EstimateMode, fc.HoursPerPoint = tt.mode, tt.hoursPerPoint
+		iss := jiraClient{fieldConfig: fc}.unmarshallIssue(raw)
+		assert.Equal(t, tt.unit, iss.EstimateUnit, tt.mode)
+		assert.Equal(t, tt.estimate, iss.Estimate, tt.mode)
+		assert.Equal(t, tt.initialEstimate, iss.InitialEstimate, tt.mode)
+		assert.Equal(t, 4.0, iss.RemainingHours, tt.mode)
+		assert.Equal(t, 6.0, iss.LoggedHours, tt.mode)
+	}
+
+	// finished work is sized by the time spent on it, since little or nothing remains
+	closed := gjson.Parse(`{"key": "JG-2", "fields": {"status": {"name": "Closed"}, "timeestimate": 3600, "timespent": 21600}}`)
+	fc.EstimateMode, fc.HoursPerPoint = EstimateHours, 0
+	assert.Equal(t, 7.0, jiraClient{fieldConfig: fc}.unmarshallIssue(closed).Estimate)
+}
@@ -0,0 +1,245 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// historyChunkSize bounds the number of keys per changelog search, keeping request URLs reasonably short
+const historyChunkSize = 100
+
+type issueMetrics struct {
+	Key     string     `json:"key"`
+	Summary string     `json:"summary"`
+	EpicKey string     `json:"epicKey"`
+	Started *time.Time `json:"started,omitempty"`
+	Done    *time.Time `json:"done,omitempty"`
+	// LeadDays runs from creation to done, CycleDays from the first start to done
+	LeadDays  *float64 `json:"leadDays,omitempty"`
+	CycleDays *float64 `json:"cycleDays,omitempty"`
+	// FlaggedDays is the time flagged as an impediment, and BlockedDays the time spent waiting on an unfinished blocker
+	// in the graph. Both only count while the issue itself is unfinished.
+	FlaggedDays float64 `json:"flaggedDays"`
+	BlockedDays float64 `json:"blockedDays"`
+	// OpenDays is the time from creation to done, or until now for unfinished issues
+	OpenDays float64 `json:"openDays"`
+}
+
+type epicMetrics struct {
+	EpicKey         string   `json:"epicKey"`
+	EpicName        string   `json:"epicName"`
+	Issues          int      `json:"issues"`
+	Completed       int      `json:"completed"`
+	MedianLeadDays  *float64 `json:"medianLeadDays,omitempty"`
+	MedianCycleDays *float64 `json:"medianCycleDays,omitempty"`
+	FlaggedDays     float64  `json:"flaggedDays"`
+	BlockedDays     float64  `json:"blockedDays"`
+	// BlockedShare is the share of the issues' open time spent waiting on blockers
+	BlockedShare float64 `json:"blockedShare"`
+}
+
+type flowMetrics struct {
+	Issues []issueMetrics `json:"issues"`
+	Epics  []epicMetrics  `json:"epics"`
+}
+
+// searchGraphHistories fetches the changelogs of every issue in the graph
+func searchGraphHistories(jc jiraClient, resp graphResponse) (issueHistories, error) {
+	result := issueHistories{histories: []issueHistory{}, fieldNames: map[string]string{}}
+	for start := 0; start < len(resp.Issues); start += historyChunkSize {
+		end := start + historyChunkSize
+		if end > len(resp.Issues) {
+			end = len(resp.Issues)
+		}
+		keys := make([]string, 0, end-start)
+		for _, iss := range resp.Issues[start:end] {
+			keys = append(keys, iss.Key)
+		}
+		chunk, err := searchIssueHistories(jc, fmt.Sprintf("key IN (%s)", strings.Join(keys, ",")))
+		if err != nil {
+			return issueHistories{}, err
+		}
+		result.histories = append(result.histories, chunk.histories...)
+		for id, name := range chunk.fieldNames {
+			result.fieldNames[id] = name
+		}
+	}
+	return result, nil
+}
+
+func toDays(d time.Duration) float64 {
+	return d.Hours() / 24
+}
+
+// startedAndDone finds when an issue first left the backlog by now, and when it last became done if it was done now
+func (h issueHistory) startedAndDone(now time.Time) (started, done time.Time) {
+	for _, c := range h.changesOf(statusField) {
+		if c.At.After(now) {
+			break
+		}
+		if started.IsZero() && categorizeStatus(c.ToString) != statusBacklog {
+			started = c.At
+		}
+		if categorizeStatus(c.ToString) == statusClosed {
+			done = c.At
+		}
+	}
+	if categorizeStatus(h.statusAt(now)) != statusClosed {
+		done = time.Time{}
+	}
+	return started, done
+}
+
+// flaggedDuration sums the time the issue was flagged as an impediment between its creation and end
+func (h issueHistory) flaggedDuration(flagged fieldMatcher, end time.Time) time.Duration {
+	current := ""
+	if h.issue.Flagged {
+		current = "Impediment"
+	}
+	isFlagged := strings.Contains(h.valueAt(flagged, current, h.created), "Impediment")
+	since := h.created
+
+	total := time.Duration(0)
+	for _, c := range h.changesOf(flagged) {
+		if c.At.After(end) {
+			break
+		}
+		if isFlagged {
+			total += c.At.Sub(since)
+		}
+		isFlagged, since = strings.Contains(c.ToString, "Impediment"), c.At
+	}
+	if isFlagged && end.After(since) {
+		total += end.Sub(since)
+	}
+	return total
+}
+
+// blockedDuration sums the time between the issue's creation and end during which any of its blockers in the graph
+// existed and wasn't done. Blockers and their statuses only change at changelog entries, so it suffices to check the
+// state at each of them.
+func (h issueHistory) blockedDuration(byKey map[string]issueHistory, end time.Time) time.Duration {
+	events := []time.Time{h.created}
+	addEvents := func(changes []change) {
+		for _, c := range changes {
+			if c.At.After(h.created) && c.At.Before(end) {
+				events = append(events, c.At)
+			}
+		}
+	}
+	addEvents(h.changesOf(linkField))
+	blockers := map[string]bool{}
+	for _, c := range h.changesOf(linkField) {
+		blockers[c.To], blockers[c.From] = true, true
+	}
+	for _, k := range h.issue.blockedByKeys {
+		blockers[k] = true
+	}
+	for k := range blockers {
+		if b, ok := byKey[k]; ok {
+			addEvents(b.changesOf(statusField))
+			if b.created.After(h.created) && b.created.Before(end) {
+				events = append(events, b.created)
+			}
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Before(events[j]) })
+
+	total := time.Duration(0)
+	for i, at := range events {
+		next := end
+		if i+1 < len(events) {
+			next = events[i+1]
+		}
+		for _, k := range h.blockedByAt(at) {
+			if b, ok := byKey[k]; ok && b.existsAt(at) && categorizeStatus(b.statusAt(at)) != statusClosed {
+				total += next.Sub(at)
+				break
+			}
+		}
+	}
+	return total
+}
+
+// computeFlowMetrics measures each issue's lead time, cycle time, and time flagged and blocked as of now, and
+// aggregates them per epic. Issues created after now are left out.
+func computeFlowMetrics(histories issueHistories, flagged fieldMatcher, epicNames map[string]string, now time.Time) flowMetrics {
+	byKey := make(map[string]issueHistory, len(histories.histories))
+	for _, h := range histories.histories {
+		byKey[h.issue.Key] = h
+	}
+	sorted := append([]issueHistory{}, histories.histories...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].issue.Key < sorted[j].issue.Key })
+
+	result := flowMetrics{Issues: []issueMetrics{}, Epics: []epicMetrics{}}
+	epics := map[string]*epicMetrics{}
+	leads, cycles := map[string][]float64{}, map[string][]float64{}
+	openDays := map[string]float64{}
+	for _, h := range sorted {
+		if !h.existsAt(now) {
+			continue
+		}
+		started, done := h.startedAndDone(now)
+		end := now
+		if !done.IsZero() {
+			end = done
+		}
+		m := issueMetrics{
+			Key:         h.issue.Key,
+			Summary:     h.issue.Summary,
+			EpicKey:     h.issue.EpicKey,
+			FlaggedDays: toDays(h.flaggedDuration(flagged, end)),
+			BlockedDays: toDays(h.blockedDuration(byKey, end)),
+			OpenDays:    toDays(end.Sub(h.created)),
+		}
+		if !started.IsZero() {
+			m.Started = &started
+		}
+		if !done.IsZero() {
+			m.Done = &done
+			lead := toDays(done.Sub(h.created))
+			m.LeadDays = &lead
+			leads[m.EpicKey] = append(leads[m.EpicKey], lead)
+			if !started.IsZero() {
+				cycle := toDays(done.Sub(started))
+				m.CycleDays = &cycle
+				cycles[m.EpicKey] = append(cycles[m.EpicKey], cycle)
+			}
+		}
+		result.Issues = append(result.Issues, m)
+
+		e, ok := epics[m.EpicKey]
+		if !ok {
+			e = &epicMetrics{EpicKey: m.EpicKey, EpicName: epicNames[m.EpicKey]}
+			epics[m.EpicKey] = e
+		}
+		e.Issues++
+		if m.Done != nil {
+			e.Completed++
+		}
+		e.FlaggedDays += m.FlaggedDays
+		e.BlockedDays += m.BlockedDays
+		openDays[m.EpicKey] += m.OpenDays
+	}
+
+	median := func(values []float64) *float64 {
+		if len(values) == 0 {
+			return nil
+		}
+		sort.Float64s(values)
+		m := percentile(values, 0.5)
+		return &m
+	}
+	for k, e := range epics {
+		e.MedianLeadDays = median(leads[k])
+		e.MedianCycleDays = median(cycles[k])
+		if openDays[k] > 0 {
+			e.BlockedShare = e.BlockedDays / openDays[k]
+		}
+		result.Epics = append(result.Epics, *e)
+	}
+	sort.Slice(result.Epics, func(i, j int) bool { return result.Epics[i].EpicKey < result.Epics[j].EpicKey })
+	return result
+}
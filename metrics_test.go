@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_computeFlowMetrics(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2021, 10, d, 0, 0, 0, 0, time.UTC) }
+	flagged := fieldMatcher{id: "customfield_10002", name: "Flagged"}
+	histories := issueHistories{histories: []issueHistory{
+		{
+			issue:   issue{Key: "JG-1", Status: "Closed", EpicKey: "JG-10"},
+			created: day(1),
+			changes: []change{
+				{At: day(2), Field: "status", FromString: "Backlog", ToString: "In Progress"},
+				{At: day(3), Field: "Flagged", ToString: "Impediment"},
+				{At: day(4), Field: "Flagged", FromString: "Impediment"},
+				{At: day(6), Field: "status", FromString: "In Progress", ToString: "Closed"},
+			},
+		},
+		{
+			issue:   issue{Key: "JG-2", Status: "In Progress", EpicKey: "JG-10", blockedByKeys: []string{"JG-1"}},
+			created: day(1),
+			changes: []change{
+				{At: day(7), Field: "status", FromString: "Backlog", ToString: "In Progress"},
+			},
+		},
+	}}
+
+	m := computeFlowMetrics(histories, flagged, map[string]string{"JG-10": "Epic"}, day(11))
+	if assert.Len(t, m.Issues, 2) {
+		assert.Equal(t, 5.0, *m.Issues[0].LeadDays)
+		assert.Equal(t, 4.0, *m.Issues[0].CycleDays)
+		assert.Equal(t, 1.0, m.Issues[0].FlaggedDays)
+		assert.Equal(t, 0.0, m.Issues[0].BlockedDays)
+
+		assert.Nil(t, m.Issues[1].LeadDays)
+		// waiting on JG-1 until it closed on day 6
+		assert.Equal(t, 5.0, m.Issues[1].BlockedDays)
+	}
+	if assert.Len(t, m.Epics, 1) {
+		e := m.Epics[0]
+		assert.Equal(t, 2, e.Issues)
+		assert.Equal(t, 1, e.Completed)
+		assert.Equal(t, 5.0, *e.MedianLeadDays)
+		assert.InDelta(t, 5.0/15, e.BlockedShare, 1e-9)
+	}
+}
+
+func Test_computeFlowMetricsInThePast(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2021, 10, d, 0, 0, 0, 0, time.UTC) }
+	histories := issueHistories{histories: []issueHistory{
+		{
+			issue:   issue{Key: "JG-1", Status: "Closed"},
+			created: day(1),
+			changes: []change{
+				{At: day(2), Field: "status", FromString: "Backlog", ToString: "In Progress"},
+				{At: day(6), Field: "status", FromString: "In Progress", ToString: "Closed"},
+			},
+		},
+		{issue: issue{Key: "JG-2", Status: "Backlog"}, created: day(8)},
+	}}
+
+	// JG-1 was still in progress on day 4, and JG-2 didn't exist yet
+	m := computeFlowMetrics(histories, fieldMatcher{}, map[string]string{}, day(4))
+	if assert.Len(t, m.Issues, 1) {
+		assert.Equal(t, day(2), *m.Issues[0].Started)
+		assert.Nil(t, m.Issues[0].Done)
+		assert.Equal(t, 3.0, m.Issues[0].OpenDays)
+	}
+}
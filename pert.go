@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"math"
+
+	"github.com/tidwall/gjson"
+)
+
+// pertZ is the normal quantile of the two-sided 90% confidence ranges reported for PERT estimates
+const pertZ = 1.645
+
+type threePointEstimate struct {
+	Optimistic  float64 `json:"optimistic"`
+	Likely      float64 `json:"likely"`
+	Pessimistic float64 `json:"pessimistic"`
+}
+
+func (e threePointEstimate) mean() float64 {
+	return (e.Optimistic + 4*e.Likely + e.Pessimistic) / 6
+}
+
+func (e threePointEstimate) variance() float64 {
+	sd := (e.Pessimistic - e.Optimistic) / 6
+	return sd * sd
+}
+
+// parseThreePoint reads the configured three-point estimate fields; issues missing any of them have no three-point
+// estimate
+func (j jiraClient) parseThreePoint(fields gjson.Result) *threePointEstimate {
+	fc := j.fieldConfig
+	if len(fc.OptimisticEstimate) == 0 || len(fc.LikelyEstimate) == 0 || len(fc.PessimisticEstimate) == 0 {
+		return nil
+	}
+	o, m, p := fields.Get(fc.OptimisticEstimate), fields.Get(fc.LikelyEstimate), fields.Get(fc.PessimisticEstimate)
+	if o.Type != gjson.Number || m.Type != gjson.Number || p.Type != gjson.Number {
+		return nil
+	}
+	return &threePointEstimate{
+		Optimistic:  fc.toEstimateUnit(fc.OptimisticEstimate, o.Float()),
+		Likely:      fc.toEstimateUnit(fc.LikelyEstimate, m.Float()),
+		Pessimistic: fc.toEstimateUnit(fc.PessimisticEstimate, p.Float()),
+	}
+}
+
+// expectedEstimate is the PERT mean of an issue's three-point estimate, or its single-point estimate without one
+func expectedEstimate(iss issue) float64 {
+	if iss.ThreePoint != nil {
+		return iss.ThreePoint.mean()
+	}
+	return iss.Estimate
+}
+
+// estimateVariance is the PERT variance of an issue's three-point estimate; single-point estimates have none
+func estimateVariance(iss issue) float64 {
+	if iss.ThreePoint != nil {
+		return iss.ThreePoint.variance()
+	}
+	return 0
+}
+
+// confidenceRange is the 90% range around a sum of PERT estimates, approximating the sum as normally distributed
+func confidenceRange(mean, variance float64) (float64, float64) {
+	spread := pertZ * math.Sqrt(variance)
+	return math.Max(0, mean-spread), mean + spread
+}
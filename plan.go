@@ -0,0 +1,177 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxPlannedSprints bounds how many new sprints a plan may propose before giving up on the remaining issues
+const maxPlannedSprints = 52
+
+// priorityRanks orders Jira's default priorities, most urgent first
+var priorityRanks = map[string]int{
+	"Highest": 0,
+	"High":    1,
+	"Medium":  2,
+	"Low":     3,
+	"Lowest":  4,
+}
+
+func priorityRank(p string) int {
+	if r, ok := priorityRanks[p]; ok {
+		return r
+	}
+	return priorityRanks["Medium"]
+}
+
+// byPriority orders issues by priority, then key
+func byPriority(a, b issue) bool {
+	if ra, rb := priorityRank(a.Priority), priorityRank(b.Priority); ra != rb {
+		return ra < rb
+	}
+	return a.Key < b.Key
+}
+
+type plannedSprint struct {
+	Name      string    `json:"name"`
+	ID        int       `json:"id,omitempty"`
+	New       bool      `json:"new"`
+	StartDate time.Time `json:"startDate"`
+	EndDate   time.Time `json:"endDate"`
+	// Scheduled is the points already in the sprint, Proposed is the points the plan adds
+	Scheduled float64 `json:"scheduled"`
+	Proposed  float64 `json:"proposed"`
+}
+
+type sprintAssignment struct {
+	Key      string  `json:"key"`
+	Summary  string  `json:"summary"`
+	Priority string  `json:"priority"`
+	Estimate float64 `json:"estimate"`
+	// CurrentSprint is the closed sprint the issue was left unfinished in, if any; otherwise it was never scheduled
+	CurrentSprint  string `json:"currentSprint"`
+	ProposedSprint string `json:"proposedSprint"`
+	// OverCapacity is set on issues too large to fit into any sprint; they get a sprint to themselves
+	OverCapacity bool `json:"overCapacity,omitempty"`
+}
+
+type sprintPlan struct {
+	Capacity float64            `json:"capacity"`
+	Sprints  []plannedSprint    `json:"sprints"`
+	Changes  []sprintAssignment `json:"changes"`
+	// Unplanned lists issues that didn't fit into maxPlannedSprints new sprints, and the issues they block
+	Unplanned []string `json:"unplanned"`
+}
+
+// planSprints proposes sprints for the unfinished issues that aren't scheduled into an open sprint. Issues are taken
+// in blocking order with priority tie-breaks, and each goes into the earliest sprint after all of its blockers' sprints
+// that still has room for its estimate. Existing open sprints are filled first, then new sprints are proposed.
+func planSprints(resp graphResponse, capacity float64, now time.Time) sprintPlan {
+	openSprints := map[int]sprint{}
+	for _, iss := range resp.Issues {
+		for _, s := range iss.Sprints {
+			if isOpenSprint(s) {
+				openSprints[s.ID] = s
+			}
+		}
+	}
+	known := make([]sprint, 0, len(openSprints))
+	for _, s := range openSprints {
+		known = append(known, s)
+	}
+	sort.Slice(known, func(i, j int) bool { return sprintBefore(known[i], known[j]) })
+
+	slots := make([]plannedSprint, len(known))
+	slotOf := map[int]int{}
+	for i, s := range known {
+		slots[i] = plannedSprint{Name: s.Name, ID: s.ID, StartDate: s.StartDate, EndDate: s.EndDate}
+		slotOf[s.ID] = i
+	}
+
+	// the slot each issue finishes in; done issues finish before every slot
+	finishedIn := map[string]int{}
+	unscheduled := []issue{}
+	for _, iss := range resp.Issues {
+		if isDone(iss) {
+			finishedIn[iss.Key] = -1
+			continue
+		}
+		if s, ok := scheduledSprint(iss); ok {
+			finishedIn[iss.Key] = slotOf[s.ID]
+			slots[slotOf[s.ID]].Scheduled += iss.Estimate
+			continue
+		}
+		unscheduled = append(unscheduled, iss)
+	}
+
+	length := medianSprintLength(known)
+	addSlot := func() {
+		start := now
+		if len(slots) > 0 && !slots[len(slots)-1].EndDate.IsZero() {
+			start = slots[len(slots)-1].EndDate
+		}
+		newCount := 0
+		for _, s := range slots {
+			if s.New {
+				newCount++
+			}
+		}
+		slots = append(slots, plannedSprint{
+			Name:      fmt.Sprintf("New sprint %d", newCount+1),
+			New:       true,
+			StartDate: start,
+			EndDate:   start.Add(length),
+		})
+	}
+
+	plan := sprintPlan{Capacity: capacity, Changes: []sprintAssignment{}, Unplanned: []string{}}
+	unplanned := map[string]bool{}
+	for _, iss := range topologicalOrderBy(unscheduled, byPriority) {
+		earliest, blockerUnplanned := 0, false
+		for _, blockedBy := range iss.blockedByKeys {
+			if slot, ok := finishedIn[blockedBy]; ok && slot+1 > earliest {
+				earliest = slot + 1
+			}
+			blockerUnplanned = blockerUnplanned || unplanned[blockedBy]
+		}
+
+		placed, overCapacity := -1, iss.Estimate > capacity
+		for slot := earliest; placed == -1 && !blockerUnplanned; slot++ {
+			if slot >= len(slots) {
+				if len(slots)-len(known) >= maxPlannedSprints {
+					break
+				}
+				addSlot()
+			}
+			used := slots[slot].Scheduled + slots[slot].Proposed
+			if used+iss.Estimate <= capacity || (overCapacity && used == 0) {
+				placed = slot
+			}
+		}
+		if placed == -1 {
+			unplanned[iss.Key] = true
+			plan.Unplanned = append(plan.Unplanned, iss.Key)
+			continue
+		}
+
+		slots[placed].Proposed += iss.Estimate
+		finishedIn[iss.Key] = placed
+		current := ""
+		if len(iss.Sprints) > 0 {
+			current = iss.Sprints[len(iss.Sprints)-1].Name
+		}
+		plan.Changes = append(plan.Changes, sprintAssignment{
+			Key:            iss.Key,
+			Summary:        iss.Summary,
+			Priority:       iss.Priority,
+			Estimate:       iss.Estimate,
+			CurrentSprint:  current,
+			ProposedSprint: slots[placed].Name,
+			OverCapacity:   overCapacity,
+		})
+	}
+
+	plan.Sprints = slots
+	return plan
+}
@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_planSprints(t *testing.T) {
+	now := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	next := sprint{ID: 5, State: "future", Name: "Sprint 5", Sequence: 5, StartDate: now, EndDate: now.AddDate(0, 0, 14)}
+	issues := []issue{
+		{Key: "JG-1", Status: "Backlog", Estimate: 5, Sprints: []sprint{next}},
+		{Key: "JG-2", Status: "Backlog", Estimate: 3, Priority: "Low"},
+		{Key: "JG-3", Status: "Backlog", Estimate: 3, Priority: "High"},
+		{Key: "JG-4", Status: "Backlog", Estimate: 2, blockedByKeys: []string{"JG-1"}},
+		{Key: "JG-5", Status: "Backlog", Estimate: 20},
+		{Key: "JG-6", Status: "Closed", Estimate: 8},
+	}
+	resp := graphResponse{Issues: issues, Graph: issuesToBlocksGraph(issues)}
+
+	plan := planSprints(resp, 8, now)
+	proposed := map[string]string{}
+	for _, c := range plan.Changes {
+		proposed[c.Key] = c.ProposedSprint
+	}
+	expected := map[string]string{
+		// the higher priority issue claims the remaining room in the existing sprint
+		"JG-3": "Sprint 5",
+		"JG-2": "New sprint 1",
+		// blocked by JG-1, so it can't share its sprint
+		"JG-4": "New sprint 1",
+		// too large for any sprint, so it gets one to itself
+		"JG-5": "New sprint 2",
+	}
+	assert.Equal(t, expected, proposed)
+	assert.Empty(t, plan.Unplanned)
+
+	if assert.Len(t, plan.Sprints, 3) {
+		assert.Equal(t, plannedSprint{Name: "Sprint 5", ID: 5, StartDate: next.StartDate, EndDate: next.EndDate, Scheduled: 5, Proposed: 3}, plan.Sprints[0])
+		assert.Equal(t, next.EndDate, plan.Sprints[1].StartDate)
+		assert.Equal(t, 5.0, plan.Sprints[1].Proposed)
+	}
+}
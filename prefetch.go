@@ -0,0 +1,189 @@
+package graph
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestBudget limits how many requests are sent to Jira per minute, across all callers. Up to a minute's worth of
+// requests can be sent in a burst.
+type requestBudget struct {
+	perMinute int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRequestBudget(perMinute int) *requestBudget {
+	return &requestBudget{perMinute: perMinute, tokens: float64(perMinute)}
+}
+
+// take spends a request from the budget at now if one is left, or returns how long until one is
+func (b *requestBudget) take(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	perSecond := float64(b.perMinute) / 60
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * perSecond
+		if b.tokens > float64(b.perMinute) {
+			b.tokens = float64(b.perMinute)
+		}
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+}
+
+// wait blocks until a request fits in the budget, or gives up when ctx is cancelled
+func (b *requestBudget) wait(ctx context.Context) error {
+	for {
+		d := b.take(time.Now())
+		if d == 0 {
+			return nil
+		}
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+type prefetchStatus struct {
+	Key            string     `json:"key"`
+	LastAttempt    *time.Time `json:"lastAttempt,omitempty"`
+	LastRefresh    *time.Time `json:"lastRefresh,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	NextRefresh    time.Time  `json:"nextRefresh"`
+	DurationMillis int64      `json:"durationMillis"`
+	Issues         int        `json:"issues"`
+}
+
+// prefetcher keeps the cached graphs of the watched epics and milestones warm, refreshing each of them every interval
+// plus a random jitter so that they don't all hit Jira at once. Visitors are served the cached graphs like any other
+// cache entry: while younger than the cache's maxAge, or while Jira is unavailable.
+type prefetcher struct {
+	jc       jiraClient
+	cache    *responseCache
+	interval time.Duration
+	jitter   time.Duration
+
+	mu     sync.Mutex
+	status map[string]*prefetchStatus
+}
+
+func newPrefetcher(jc jiraClient, cache *responseCache, keys []string, interval, jitter time.Duration) *prefetcher {
+	p := &prefetcher{jc: jc, cache: cache, interval: interval, jitter: jitter, status: map[string]*prefetchStatus{}}
+	for _, key := range keys {
+		key = strings.ToUpper(key)
+		p.status[key] = &prefetchStatus{Key: key}
+	}
+	return p
+}
+
+func (p *prefetcher) delay(base time.Duration) time.Duration {
+	if p.jitter <= 0 {
+		return base
+	}
+	return base + time.Duration(rand.Int63n(int64(p.jitter)))
+}
+
+// start refreshes every watched graph within the first jitter, and then every interval plus jitter
+func (p *prefetcher) start() {
+	for key := range p.status {
+		go func(key string) {
+			next := p.delay(0)
+			for {
+				p.setNext(key, time.Now().Add(next))
+				time.Sleep(next)
+				p.refresh(key)
+				next = p.delay(p.interval)
+			}
+		}(key)
+	}
+}
+
+func (p *prefetcher) setNext(key string, at time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status[key].NextRefresh = at
+}
+
+func (p *prefetcher) refresh(key string) {
+	started := time.Now()
+	var resp graphResponse
+	loader, err := keyGraphLoader(p.jc, key)
+	if err == nil {
+		resp, err = loader.load(p.jc, key)
+	}
+	if err == nil {
+		err = p.cache.storeSince(loader.cacheKind, key, resp, started)
+	}
+	finished := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.status[key]
+	s.LastAttempt = &finished
+	s.DurationMillis = finished.Sub(started).Milliseconds()
+	if err != nil {
+		log.Printf("failed to prefetch the graph of %s: %v", key, err)
+		s.LastError = err.Error()
+		return
+	}
+	s.LastRefresh = &finished
+	s.LastError = ""
+	s.Issues = len(resp.Issues)
+}
+
+func (p *prefetcher) statuses() []prefetchStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := make([]prefetchStatus, 0, len(p.status))
+	for _, s := range p.status {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// requireAdminToken rejects requests without an 'Authorization: Bearer <token>' header
+func requireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": http.StatusText(http.StatusUnauthorized)})
+		}
+	}
+}
+
+// getPrefetchStatus reports when each prefetched graph was last refreshed, and the Jira request budget
+func (gc graphController) getPrefetchStatus(p *prefetcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		budget := 0
+		if gc.jc.budget != nil {
+			budget = gc.jc.budget.perMinute
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"interval":          p.interval.String(),
+			"jitter":            p.jitter.String(),
+			"requestsPerMinute": budget,
+			"graphs":            p.statuses(),
+		})
+	}
+}
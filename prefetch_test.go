@@ -0,0 +1,98 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_requestBudget(t *testing.T) {
+	b := newRequestBudget(2)
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	// a minute's worth of requests are allowed in a burst
+	assert.Equal(t, time.Duration(0), b.take(now))
+	assert.Equal(t, time.Duration(0), b.take(now))
+	assert.Equal(t, 30*time.Second, b.take(now))
+
+	// and then one every 30 seconds
+	assert.Equal(t, 20*time.Second, b.take(now.Add(10*time.Second)))
+	assert.Equal(t, time.Duration(0), b.take(now.Add(30*time.Second)))
+
+	// unused requests don't accumulate beyond a minute's worth
+	later := now.Add(time.Hour)
+	assert.Equal(t, time.Duration(0), b.take(later))
+	assert.Equal(t, time.Duration(0), b.take(later))
+	assert.Equal(t, 30*time.Second, b.take(later))
+}
+
+func Test_requestBudgetWaitCancelled(t *testing.T) {
+	b := newRequestBudget(1)
+	assert.NoError(t, b.wait(context.Background()))
+
+	// the next request would wait for a minute, unless its client goes away
+	ctx, cancel := context.WithCancel(context.Background())
+	go cancel()
+	assert.Equal(t, context.Canceled, b.wait(ctx))
+
+	// nor is a cancelled request sent to Jira after all
+	jc := jiraClient{host: "jira.invalid", budget: b, ctx: ctx}
+	_, err := jc.Get("/rest/api/2/search", nil)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func Test_requireAdminToken(t *testing.T) {
+	r := gin.New()
+	r.GET("/api/admin/prefetch", requireAdminToken("token"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	for header, code := range map[string]int{"": http.StatusUnauthorized, "Bearer other": http.StatusUnauthorized, "Bearer token": http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/prefetch", nil)
+		req.Header.Set("Authorization", header)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, code, w.Code, header)
+	}
+}
+
+func Test_prefetchedGraphs(t *testing.T) {
+	jc := fakeJira{searches: map[string]string{
+		`id=JG-10`: "[" + fakeIssue("JG-10", "Milestone", "") + "]",
+		`issue IN linkedIssues("JG-10") AND type=epic`: "[" + fakeIssue("JG-1", "Epic", "") + "]",
+		`"customfield_10014" IN (JG-1)`:                "[" + fakeIssue("JG-2", "Story", "JG-1") + "]",
+	}, epics: map[string]string{"JG-1": "Epic"}}.client(t)
+	dir := t.TempDir()
+	p := newPrefetcher(jc, newResponseCache(dir, 0), []string{"jg-10"}, time.Hour, time.Minute)
+	p.refresh("JG-10")
+	status := p.statuses()[0]
+	assert.Empty(t, status.LastError)
+	assert.NotNil(t, status.LastRefresh)
+	assert.Equal(t, 1, status.Issues)
+
+	// without a max age, visitors still ask Jira, and only fall back on the prefetched graph while it's unavailable
+	unavailable := func() (interface{}, error) {
+		return nil, &url.Error{Op: "Get", URL: "https://jira", Err: errors.New("timeout")}
+	}
+	var resp graphResponse
+	s, err := newResponseCache(dir, 0).cached(milestoneGraphs.cacheKind, "JG-10", &resp, unavailable)
+	assert.NoError(t, err)
+	assert.True(t, s.Stale)
+	assert.Equal(t, "JG-2", resp.Issues[0].Key)
+
+	// with one, the prefetched graph is served without asking Jira
+	rc := newResponseCache(dir, time.Hour)
+	unexpected := func() (interface{}, error) { return nil, errBadStatus{404} }
+	s, err = rc.cached(milestoneGraphs.cacheKind, "JG-10", &resp, unexpected)
+	assert.NoError(t, err)
+	assert.False(t, s.Stale)
+	assert.Equal(t, "JG-2", resp.Issues[0].Key)
+
+	// the key is prefetched as a milestone, so loading it as an epic still asks Jira
+	_, err = rc.cached(epicGraphs.cacheKind, "JG-10", &resp, unexpected)
+	assert.Equal(t, errBadStatus{404}, err)
+}
@@ -0,0 +1,72 @@
+package graph
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// progressPage reports a page of search results fetched, with the number of results so far out of the total
+	progressPage = "page"
+	// progressIssues carries the issues of a page, before their epic names and colors are resolved
+	progressIssues = "issues"
+	// progressEpic reports an epic's name and color resolved, with the number of epics so far out of the total
+	progressEpic  = "epic"
+	progressGraph = "graph"
+	progressError = "error"
+)
+
+type loadProgress struct {
+	Type   string         `json:"type"`
+	JQL    string         `json:"jql,omitempty"`
+	Key    string         `json:"key,omitempty"`
+	Done   int            `json:"done,omitempty"`
+	Total  int            `json:"total,omitempty"`
+	Issues []issue        `json:"issues,omitempty"`
+	Graph  *graphResponse `json:"graph,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+func (j jiraClient) report(p loadProgress) {
+	if j.progress != nil {
+		j.progress(p)
+	}
+}
+
+// streamGraph loads a graph while streaming its progress as newline-delimited JSON: pages fetched, batches of issues
+// and epics resolved as they arrive, ending with the full graph or an error. The response starts with the first
+// progress, so that failures before it, such as an unknown key, are answered with an error status instead.
+func (gc graphController) streamGraph(loader graphLoader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		enc := json.NewEncoder(c.Writer)
+		started := false
+		send := func(p loadProgress) {
+			if !started {
+				c.Header("Content-Type", "application/x-ndjson")
+				c.Header("Cache-Control", "no-cache")
+				c.Header("X-Accel-Buffering", "no")
+				c.Status(http.StatusOK)
+				started = true
+			}
+			// the client may have gone away, in which case the rest of the load is wasted but harmless
+			if err := enc.Encode(p); err == nil {
+				c.Writer.Flush()
+			}
+		}
+
+		// progress is reported synchronously from this goroutine, so it's safe to write to the response
+		jc := gc.client(c)
+		jc.progress = send
+		resp, err := loader.load(jc, c.Param("key"))
+		if err != nil && !started {
+			respondWithError(c, err)
+			return
+		} else if err != nil {
+			send(loadProgress{Type: progressError, Error: err.Error()})
+			return
+		}
+		send(loadProgress{Type: progressGraph, Graph: &resp})
+	}
+}
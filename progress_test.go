@@ -0,0 +1,78 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func progressJira(t *testing.T) jiraClient {
+	return fakeJira{
+		searches: map[string]string{
+			`issue IN linkedIssues("JG-10") AND type=epic`: "[" + fakeIssue("JG-1", "Epic", "JG-20") + "]",
+			`"customfield_10014" IN (JG-1)`:                "[" + fakeIssue("JG-2", "Story", "JG-1") + "," + fakeIssue("JG-3", "Story", "JG-1", "JG-2") + "]",
+		},
+		epics:    map[string]string{"JG-1": "Epic", "JG-20": "Initiative"},
+		pageSize: 1,
+	}.client(t)
+}
+
+func Test_loadProgress(t *testing.T) {
+	for _, loader := range graphLoaders {
+		jc := progressJira(t)
+		reported := []loadProgress{}
+		jc.progress = func(p loadProgress) {
+			reported = append(reported, p)
+		}
+		key := "JG-1"
+		if loader.cacheKind == milestoneGraphs.cacheKind {
+			key = "JG-10"
+		}
+		_, err := loader.load(jc, key)
+		assert.NoError(t, err)
+
+		// a milestone's epics are looked up first, but aren't reported since they aren't part of the graph
+		jql := `"customfield_10014" IN (JG-1)`
+		if assert.Len(t, reported, 5, loader.cacheKind) {
+			assert.Equal(t, progressIssues, reported[0].Type)
+			assert.Equal(t, "JG-2", reported[0].Issues[0].Key)
+			assert.Equal(t, loadProgress{Type: progressPage, JQL: jql, Done: 1, Total: 2}, reported[1])
+			assert.Equal(t, "JG-3", reported[2].Issues[0].Key)
+			assert.Equal(t, loadProgress{Type: progressPage, JQL: jql, Done: 2, Total: 2}, reported[3])
+			assert.Equal(t, loadProgress{Type: progressEpic, Key: "JG-1", Done: 1, Total: 1}, reported[4])
+		}
+	}
+}
+
+func Test_streamGraph(t *testing.T) {
+	gc := graphController{jc: progressJira(t)}
+	r := gin.New()
+	r.GET("/api/milestones/:key/stream", gc.streamGraph(milestoneGraphs))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/milestones/JG-10/stream", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	types := []string{}
+	var last loadProgress
+	lines := bufio.NewScanner(w.Body)
+	for lines.Scan() {
+		assert.NoError(t, json.Unmarshal(lines.Bytes(), &last))
+		types = append(types, last.Type)
+	}
+	assert.Equal(t, []string{progressIssues, progressPage, progressIssues, progressPage, progressEpic, progressGraph}, types)
+	if assert.NotNil(t, last.Graph) {
+		assert.Len(t, last.Graph.Issues, 2)
+		assert.Equal(t, map[string][]string{"JG-2": {"JG-3"}, "JG-3": {}}, last.Graph.Graph)
+	}
+
+	// unknown keys fail before anything is streamed
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/milestones/JG-99/stream", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
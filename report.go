@@ -0,0 +1,224 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Report writes a Markdown status report for an epic or milestone key to w
+func Report(user, pass, jiraHost string, fc FieldConfig, key string, w io.Writer) error {
+	useStatusCategories(fc)
+	jc := jiraClient{
+		host:        jiraHost,
+		user:        user,
+		pass:        pass,
+		fieldConfig: fc,
+	}
+	data, err := getReportData(jc, key)
+	if err != nil {
+		return err
+	}
+	return writeMarkdownReport(w, data)
+}
+
+type reportData struct {
+	issue     issue
+	epics     []issue
+	resp      graphResponse
+	velocity  *boardVelocity
+	generated time.Time
+}
+
+func getReportData(jc jiraClient, key string) (reportData, error) {
+	iss, err := getSingleIssue(jc, key)
+	if err != nil {
+		return reportData{}, err
+	}
+	epics := []issue{iss}
+	if iss.Type == "Milestone" {
+		epics, err = getMilestoneEpics(jc, key)
+		if err != nil {
+			return reportData{}, err
+		}
+	}
+	resp, err := getEpicsGraph(jc, epics)
+	if err != nil {
+		return reportData{}, err
+	}
+	data := reportData{issue: iss, epics: epics, resp: resp, generated: time.Now()}
+
+	if boardID, ok := inferBoardID(resp.Issues); ok {
+		v, err := getBoardVelocity(jc, boardID, defaultVelocitySprints)
+		if err != nil {
+			log.Printf("failed to get the velocity of board %d: %v", boardID, err)
+		} else {
+			data.velocity = &v
+		}
+	}
+	return data, nil
+}
+
+func formatPoints(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// formatIssuePoints shows an issue's PERT estimate alongside its three points when it has them
+func formatIssuePoints(iss issue) string {
+	if iss.ThreePoint == nil {
+		return formatPoints(iss.Estimate)
+	}
+	e := iss.ThreePoint
+	return fmt.Sprintf("%s (%s/%s/%s)", formatPoints(math.Round(e.mean()*10)/10), formatPoints(e.Optimistic), formatPoints(e.Likely), formatPoints(e.Pessimistic))
+}
+
+// formatRange describes the confidence range of summed PERT estimates, if there is any uncertainty to describe
+func formatRange(mean, variance float64) string {
+	if variance == 0 {
+		return ""
+	}
+	low, high := confidenceRange(mean, variance)
+	return fmt.Sprintf(" (90%% range %s to %s)", formatPoints(math.Round(low*10)/10), formatPoints(math.Round(high*10)/10))
+}
+
+// mdEscape keeps Jira text from breaking Markdown tables and emphasis
+func mdEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`").Replace(s)
+}
+
+func mdIssue(iss issue) string {
+	s := fmt.Sprintf("%s %s", iss.Key, mdEscape(iss.Summary))
+	if len(iss.Assignee) > 0 {
+		s += fmt.Sprintf(" (%s)", mdEscape(iss.Assignee))
+	}
+	return s
+}
+
+func writeMarkdownReport(w io.Writer, data reportData) error {
+	var sb strings.Builder
+	issues := data.resp.Issues
+	unit := data.issue.EstimateUnit
+	if len(unit) == 0 {
+		unit = "points"
+	}
+	unitTitle := strings.ToUpper(unit[:1]) + unit[1:]
+	byKey := make(map[string]issue, len(issues))
+	for _, iss := range issues {
+		byKey[iss.Key] = iss
+	}
+
+	fmt.Fprintf(&sb, "# %s %s: status report %s\n\n", data.issue.Key, mdEscape(data.issue.Summary), data.generated.Format("2006-01-02"))
+
+	fmt.Fprintf(&sb, "## %s by status\n\n| Status | %s |\n| --- | ---: |\n", unitTitle, unitTitle)
+	byStatus := pointsByStatus(issues)
+	statuses := make([]string, 0, len(byStatus))
+	total := 0.0
+	for s, points := range byStatus {
+		statuses = append(statuses, s)
+		total += points
+	}
+	statusOrder(statuses)
+	for _, s := range statuses {
+		fmt.Fprintf(&sb, "| %s | %s |\n", mdEscape(s), formatPoints(byStatus[s]))
+	}
+	fmt.Fprintf(&sb, "| **Total** | **%s** |\n\n", formatPoints(total))
+	if total > 0 {
+		closed := byStatus[statusClosed]
+		fmt.Fprintf(&sb, "%s/%s %s closed (%d%%)\n\n", formatPoints(closed), formatPoints(total), unit, int(math.Round(closed/total*100)))
+	}
+
+	initial := 0.0
+	for _, epic := range data.epics {
+		initial += epic.InitialEstimate
+	}
+	sb.WriteString("## Estimate\n\n")
+	if initial > 0 {
+		change := total - initial
+		fmt.Fprintf(&sb, "Initial estimate: %s, current estimate: %s (%+g, %+d%%)\n\n", formatPoints(initial), formatPoints(total), change, int(math.Round(change/initial*100)))
+	} else {
+		fmt.Fprintf(&sb, "No initial estimate, current estimate: %s\n\n", formatPoints(total))
+	}
+	remaining, variance := 0.0, 0.0
+	for _, iss := range issues {
+		if !isDone(iss) {
+			remaining += expectedEstimate(iss)
+			variance += estimateVariance(iss)
+		}
+	}
+	if variance > 0 {
+		fmt.Fprintf(&sb, "Remaining PERT estimate: %s %s%s\n\n", formatPoints(math.Round(remaining*10)/10), unit, formatRange(remaining, variance))
+	}
+
+	if data.velocity != nil && len(data.velocity.Sprints) > 0 {
+		v := data.velocity
+		completed := make([]string, len(v.Sprints))
+		for i := range v.Sprints {
+			completed[i] = formatPoints(v.Sprints[i].Completed)
+		}
+		sb.WriteString("## Velocity\n\n")
+		fmt.Fprintf(&sb, "Board %d completed %s %s per sprint on average over its last %d closed sprints (%s).", v.BoardID, formatPoints(math.Round(v.Average*10)/10), unit, len(v.Sprints), strings.Join(completed, ", "))
+		if remaining := total - byStatus[statusClosed]; v.Average > 0 && remaining > 0 {
+			fmt.Fprintf(&sb, " At that pace, the remaining %s %s take about %.1f sprints.", formatPoints(remaining), unit, remaining/v.Average)
+		}
+		sb.WriteString("\n\n")
+	}
+
+	sb.WriteString("## Flagged\n\n")
+	flagged := 0
+	for _, iss := range sortedByKey(issues) {
+		if iss.Flagged && !isDone(iss) {
+			fmt.Fprintf(&sb, "- %s\n", mdIssue(iss))
+			flagged++
+		}
+	}
+	if flagged == 0 {
+		sb.WriteString("Nothing is flagged.\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("## Newly unblocked\n\n")
+	unblocked := newlyUnblocked(issues)
+	for _, iss := range sortedByKey(unblocked) {
+		fmt.Fprintf(&sb, "- %s, %s %s\n", mdIssue(iss), formatPoints(iss.Estimate), unit)
+	}
+	if len(unblocked) == 0 {
+		sb.WriteString("No work has been unblocked by closed blockers.\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("## Blocked chains\n\n")
+	chains := blockedChains(data.resp)
+	for _, chain := range chains {
+		fmt.Fprintf(&sb, "- %s (%s %s)\n", strings.Join(chain.keys, " → "), formatPoints(chain.estimate), unit)
+	}
+	if len(chains) == 0 {
+		sb.WriteString("No unfinished work is waiting on unfinished blockers.\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("## Critical path\n\n")
+	path := criticalPath(issues)
+	if len(path.keys) == 0 {
+		sb.WriteString("All work is done.\n")
+	} else {
+		fmt.Fprintf(&sb, "%d issues, %s remaining %s%s\n\n| # | Issue | Status | %s | Assignee |\n| ---: | --- | --- | ---: | --- |\n", len(path.keys), formatPoints(math.Round(path.estimate*10)/10), unit, formatRange(path.estimate, path.variance), unitTitle)
+		for i, k := range path.keys {
+			iss := byKey[k]
+			fmt.Fprintf(&sb, "| %d | %s %s | %s | %s | %s |\n", i+1, iss.Key, mdEscape(iss.Summary), mdEscape(iss.Status), formatIssuePoints(iss), mdEscape(iss.Assignee))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func sortedByKey(issues []issue) []issue {
+	sorted := append([]issue{}, issues...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	return sorted
+}
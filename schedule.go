@@ -0,0 +1,158 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	unassignedWorker = "Unassigned"
+	// maxScheduleDevelopers bounds the workers a single request can simulate
+	maxScheduleDevelopers = 500
+	// maxScheduleYears bounds how far ahead a schedule can run, well within what a time.Duration can hold
+	maxScheduleYears = 100
+)
+
+type scheduledIssue struct {
+	Key      string    `json:"key"`
+	Summary  string    `json:"summary"`
+	Worker   string    `json:"worker"`
+	Estimate float64   `json:"estimate"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+}
+
+type workerTimeline struct {
+	Worker string           `json:"worker"`
+	Issues []scheduledIssue `json:"issues"`
+	End    time.Time        `json:"end"`
+}
+
+type schedule struct {
+	// Rate is the points a single worker completes per day
+	Rate      float64          `json:"rate"`
+	Timelines []workerTimeline `json:"timelines"`
+	End       time.Time        `json:"end"`
+}
+
+type scheduleOptions struct {
+	// developers replaces the issues' assignees with this many anonymous, interchangeable developers when positive
+	developers int
+	rate       float64
+}
+
+// simulateSchedule list-schedules the unfinished issues in blocking order with priority tie-breaks. Each issue starts
+// once its worker is free and its in-graph blockers are finished. Assigned issues stay with their assignee; unassigned
+// ones, or every issue when scheduling anonymous developers, go to whichever worker can start them first. It fails when
+// the schedule would run more than maxScheduleYears.
+func simulateSchedule(resp graphResponse, opts scheduleOptions, now time.Time) (schedule, error) {
+	limit := now.AddDate(maxScheduleYears, 0, 0)
+	order := topologicalOrderBy(resp.Issues, byPriority)
+
+	free := map[string]time.Time{}
+	if opts.developers > 0 {
+		for i := 1; i <= opts.developers; i++ {
+			free[fmt.Sprintf("Developer %d", i)] = now
+		}
+	} else {
+		for _, iss := range order {
+			if len(iss.Assignee) > 0 {
+				free[iss.Assignee] = now
+			}
+		}
+		if len(free) == 0 {
+			free[unassignedWorker] = now
+		}
+	}
+	workers := make([]string, 0, len(free))
+	for w := range free {
+		workers = append(workers, w)
+	}
+	sort.Strings(workers)
+
+	finished := map[string]time.Time{}
+	byWorker := map[string][]scheduledIssue{}
+	end := now
+	for _, iss := range order {
+		ready := now
+		for _, blockedBy := range iss.blockedByKeys {
+			if f, ok := finished[blockedBy]; ok && f.After(ready) {
+				ready = f
+			}
+		}
+
+		worker := iss.Assignee
+		if _, ok := free[worker]; opts.developers > 0 || !ok {
+			// the worker that can start soonest; ties go to the first by name
+			worker = ""
+			var bestStart time.Time
+			for _, w := range workers {
+				start := latest(free[w], ready)
+				if worker == "" || start.Before(bestStart) {
+					worker, bestStart = w, start
+				}
+			}
+		}
+
+		start := latest(free[worker], ready)
+		days := iss.Estimate / opts.rate
+		if days > maxScheduleYears*365 {
+			return schedule{}, fmt.Errorf("%s alone takes more than %d years at this rate", iss.Key, maxScheduleYears)
+		}
+		finish := start.Add(time.Duration(days * float64(24*time.Hour)))
+		if finish.After(limit) {
+			return schedule{}, fmt.Errorf("the schedule runs more than %d years at this rate", maxScheduleYears)
+		}
+		free[worker] = finish
+		finished[iss.Key] = finish
+		if finish.After(end) {
+			end = finish
+		}
+		byWorker[worker] = append(byWorker[worker], scheduledIssue{
+			Key:      iss.Key,
+			Summary:  iss.Summary,
+			Worker:   worker,
+			Estimate: iss.Estimate,
+			Start:    start,
+			End:      finish,
+		})
+	}
+
+	result := schedule{Rate: opts.rate, Timelines: []workerTimeline{}, End: end}
+	for _, w := range workers {
+		issues := byWorker[w]
+		if issues == nil {
+			issues = []scheduledIssue{}
+		}
+		result.Timelines = append(result.Timelines, workerTimeline{Worker: w, Issues: issues, End: free[w]})
+	}
+	return result, nil
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// mermaidGantt renders the schedule as a Mermaid gantt chart with a section per worker
+func (s schedule) mermaidGantt(title string) string {
+	const layout = "2006-01-02 15:04"
+	clean := strings.NewReplacer(":", " ", "#", " ", ";", " ", "\n", " ")
+
+	var sb strings.Builder
+	sb.WriteString("gantt\n")
+	fmt.Fprintf(&sb, "  title %s\n", clean.Replace(title))
+	sb.WriteString("  dateFormat YYYY-MM-DD HH:mm\n")
+	sb.WriteString("  axisFormat %Y-%m-%d\n")
+	for _, t := range s.Timelines {
+		fmt.Fprintf(&sb, "  section %s\n", clean.Replace(t.Worker))
+		for _, iss := range t.Issues {
+			fmt.Fprintf(&sb, "  %s %s :%s, %s, %s\n", iss.Key, clean.Replace(iss.Summary), mermaidID(iss.Key), iss.Start.Format(layout), iss.End.Format(layout))
+		}
+	}
+	return sb.String()
+}
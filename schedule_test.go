@@ -0,0 +1,46 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_simulateSchedule(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := func(n float64) time.Time { return now.Add(time.Duration(n * float64(24*time.Hour))) }
+
+	t.Run("assignees", func(t *testing.T) {
+		resp := testGraph()
+		resp.Issues[1].Assignee = "Bo"
+		resp.Issues[2].Assignee = "Ann"
+		s, err := simulateSchedule(resp, scheduleOptions{rate: 1}, now)
+		assert.NoError(t, err)
+
+		starts := map[string]time.Time{}
+		for _, tl := range s.Timelines {
+			for _, iss := range tl.Issues {
+				starts[iss.Key] = iss.Start
+			}
+		}
+		// JG-4 is unassigned and waits on JG-2, which Bo finishes after five days
+		assert.Equal(t, day(5), starts["JG-4"])
+		assert.Equal(t, day(8), s.End)
+	})
+
+	t.Run("anonymous developers", func(t *testing.T) {
+		s, err := simulateSchedule(testGraph(), scheduleOptions{developers: 2, rate: 2}, now)
+		assert.NoError(t, err)
+		assert.Len(t, s.Timelines, 2)
+		assert.Equal(t, day(4), s.End)
+	})
+
+	t.Run("too slow", func(t *testing.T) {
+		_, err := simulateSchedule(testGraph(), scheduleOptions{rate: 1e-300}, now)
+		assert.Error(t, err)
+		// every issue fits on its own, but not one after the other
+		_, err = simulateSchedule(testGraph(), scheduleOptions{developers: 1, rate: 0.0002}, now)
+		assert.Error(t, err)
+	})
+}
@@ -1,13 +1,19 @@
 package graph
 
 import (
+	"bytes"
 	"embed"
+	"fmt"
 	"html/template"
 	"io/fs"
 	"log"
+	"math"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,15 +23,74 @@ var (
 	distFS embed.FS
 )
 
-func StartServer(user, pass, jiraHost string, fc FieldConfig) error {
+// ServerOptions configure the server's optional features
+type ServerOptions struct {
+	// HistoryDir enables storing graph snapshots of the Watch keys every HistoryInterval, and serving them with '?at='.
+	// Snapshots older than HistoryRetention are removed, unless it's zero.
+	HistoryDir       string
+	Watch            []string
+	HistoryInterval  time.Duration
+	HistoryRetention time.Duration
+	// CacheDir enables keeping the last graphs and issues fetched from Jira, to serve while Jira is unavailable.
+	// Entries younger than CacheMaxAge are served without asking Jira.
+	CacheDir    string
+	CacheMaxAge time.Duration
+	// WebhookSecret enables '/webhooks/jira', which invalidates cache entries when issues change
+	WebhookSecret string
+	// Prefetch keys have their graphs refreshed into the cache every PrefetchInterval plus up to PrefetchJitter. It
+	// requires CacheDir.
+	Prefetch         []string
+	PrefetchInterval time.Duration
+	PrefetchJitter   time.Duration
+	// JiraRequestsPerMinute, when positive, limits the requests sent to Jira
+	JiraRequestsPerMinute int
+	// AdminToken enables '/api/admin' endpoints for requests bearing it
+	AdminToken string
+}
+
+func StartServer(user, pass, jiraHost string, fc FieldConfig, opts ServerOptions) error {
+	useStatusCategories(fc)
 	jc := jiraClient{
 		host:        jiraHost,
 		user:        user,
 		pass:        pass,
 		fieldConfig: fc,
 	}
+	if opts.JiraRequestsPerMinute > 0 {
+		jc.budget = newRequestBudget(opts.JiraRequestsPerMinute)
+	}
 	gc := graphController{
-		jc: jc,
+		jc:     jc,
+		events: newGraphEvents(),
+	}
+	if len(opts.CacheDir) > 0 {
+		gc.cache = newResponseCache(opts.CacheDir, opts.CacheMaxAge)
+	}
+	if len(opts.HistoryDir) > 0 {
+		gc.history = &historyStore{dir: opts.HistoryDir}
+		if len(opts.Watch) > 0 {
+			if opts.HistoryInterval <= 0 {
+				return fmt.Errorf("history interval must be positive")
+			}
+			go recordHistory(jc, *gc.history, opts.Watch, opts.HistoryInterval, opts.HistoryRetention)
+		}
+	}
+
+	var prefetch *prefetcher
+	if len(opts.Prefetch) > 0 {
+		if gc.cache == nil {
+			return fmt.Errorf("prefetching requires a cache directory")
+		}
+		if opts.PrefetchInterval <= 0 {
+			return fmt.Errorf("prefetch interval must be positive")
+		}
+		for _, key := range opts.Prefetch {
+			if !issueKeyPattern.MatchString(key) {
+				return fmt.Errorf("%q is not an issue key", key)
+			}
+		}
+		prefetch = newPrefetcher(jc, gc.cache, opts.Prefetch, opts.PrefetchInterval, opts.PrefetchJitter)
+		prefetch.start()
 	}
 
 	r := gin.Default()
@@ -41,10 +106,43 @@ func StartServer(user, pass, jiraHost string, fc FieldConfig) error {
 	r.SetHTMLTemplate(templates)
 
 	r.GET("/api/epics/:key", gc.getEpicGraph)
+	r.GET("/api/epics/:key/burnup", gc.getEpicBurnup)
 	r.GET("/api/issues/:key", gc.getIssue)
 	r.GET("/api/issues/:key/related", gc.getRelatedIssues)
 	r.GET("/api/issues/:key/details", gc.redirectToJIRA)
+	r.GET("/api/issues/:key/snapshot", gc.getSnapshot)
+	r.GET("/api/issues/:key/report", gc.getReport)
 	r.GET("/api/milestones/:key", gc.getMilestoneGraph)
+	r.GET("/api/epics/:key/events", gc.streamGraphEvents(epicGraphs))
+	r.GET("/api/milestones/:key/events", gc.streamGraphEvents(milestoneGraphs))
+	r.GET("/api/epics/:key/stream", gc.streamGraph(epicGraphs))
+	r.GET("/api/milestones/:key/stream", gc.streamGraph(milestoneGraphs))
+	gc.graphAnalysis(r, "sprint-check", func(c *gin.Context, resp graphResponse) (interface{}, error) {
+		return checkSprintPlan(resp), nil
+	})
+	gc.graphAnalysis(r, "plan", gc.planSprints)
+	gc.graphAnalysis(r, "schedule", scheduleGraph)
+	gc.graphAnalysis(r, "deadlines", gc.analyzeDeadlines)
+	gc.loaderAnalysis(r, "diff", gc.diffGraph)
+	gc.graphAnalysis(r, "metrics", gc.flowMetrics)
+	gc.graphAnalysis(r, "cfd", gc.cumulativeFlow)
+	gc.graphAnalysis(r, "timeline", func(c *gin.Context, resp graphResponse) (interface{}, error) {
+		return timelineGraph(c, resp)
+	})
+	gc.graphAnalysis(r, "timeline.ics", func(c *gin.Context, resp graphResponse) (interface{}, error) {
+		t, err := timelineGraph(c, resp)
+		if err != nil {
+			return nil, err
+		}
+		return rawResult{contentType: "text/calendar; charset=utf-8", body: []byte(t.iCalendar(c.Param("key"), time.Now()))}, nil
+	})
+	r.GET("/api/boards/:id/velocity", gc.getBoardVelocity)
+	if prefetch != nil && len(opts.AdminToken) > 0 {
+		r.GET("/api/admin/prefetch", requireAdminToken(opts.AdminToken), gc.getPrefetchStatus(prefetch))
+	}
+	if len(opts.WebhookSecret) > 0 {
+		r.POST("/webhooks/jira", gc.receiveWebhook(opts.WebhookSecret))
+	}
 
 	spaHandler := func(c *gin.Context) {
 		c.HTML(http.StatusOK, "index.html", nil)
@@ -65,83 +163,421 @@ func StartServer(user, pass, jiraHost string, fc FieldConfig) error {
 
 type graphController struct {
 	jc jiraClient
+	// history is nil unless snapshots are stored
+	history *historyStore
+	// cache is nil unless responses are cached
+	cache  *responseCache
+	events *graphEvents
 }
 
 type graphResponse struct {
-	Issues []issue             `json:"issues"`
-	Graph  map[string][]string `json:"graph"`
+	Issues   []issue             `json:"issues"`
+	Graph    map[string][]string `json:"graph"`
+	Forecast *forecast           `json:"forecast,omitempty"`
+	// SnapshotAt is the time a stored or reconstructed graph shows; it is unset for graphs fresh from Jira
+	SnapshotAt *time.Time `json:"snapshotAt,omitempty"`
+	staleness
 }
 
-func (gc graphController) getEpicGraph(c *gin.Context) {
-	issues, err := getIssues(gc.jc, c.Param("key"))
+// shownAt is when the graph was as shown: its snapshot time, or now for graphs fresh from Jira
+func (resp graphResponse) shownAt() time.Time {
+	if resp.SnapshotAt != nil {
+		return *resp.SnapshotAt
+	}
+	return time.Now()
+}
+
+// client is the Jira client for a request, whose Jira requests are abandoned when the request is cancelled
+func (gc graphController) client(c *gin.Context) jiraClient {
+	jc := gc.jc
+	jc.ctx = c.Request.Context()
+	return jc
+}
+
+// loadGraph fetches a graph from Jira, or with '?at=' serves the stored snapshot closest to that time
+func (gc graphController) loadGraph(c *gin.Context, loader graphLoader) (graphResponse, error) {
+	raw, ok := c.GetQuery("at")
+	if !ok {
+		return gc.fetchGraph(gc.client(c), c.Param("key"), loader)
+	}
+	at, err := parseAt(raw)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"status": http.StatusText(http.StatusInternalServerError)})
-		return
+		return graphResponse{}, err
 	}
+	return gc.graphAt(c, loader, at)
+}
 
-	if len(issues) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"status": http.StatusText(http.StatusNotFound)})
-		return
+// fetchGraph loads a graph from Jira, falling back to the cache while Jira is unavailable
+func (gc graphController) fetchGraph(jc jiraClient, key string, loader graphLoader) (graphResponse, error) {
+	if gc.cache == nil {
+		return loader.load(jc, key)
 	}
+	var resp graphResponse
+	s, err := gc.cache.cached(loader.cacheKind, key, &resp, func() (interface{}, error) {
+		return loader.load(jc.detached(), key)
+	})
+	resp.staleness = s
+	return resp, err
+}
 
-	resp := graphResponse{
-		Issues: issues,
-		Graph:  issuesToBlocksGraph(issues),
+// graphAt serves the stored snapshot of a graph closest to a time, or with '?reconstruct=true' rebuilds the graph as
+// it was at that time from its issues' changelogs
+func (gc graphController) graphAt(c *gin.Context, loader graphLoader, at time.Time) (graphResponse, error) {
+	if c.Query("reconstruct") == "true" {
+		jc := gc.client(c)
+		jc.asOf = at
+		resp, err := loader.load(jc, c.Param("key"))
+		if err != nil {
+			return graphResponse{}, err
+		}
+		resp.SnapshotAt = &at
+		return resp, nil
 	}
-	c.JSON(http.StatusOK, resp)
+	if gc.history == nil {
+		return graphResponse{}, errInvalidQuery{"a past graph requires the server to store history, or reconstruct=true"}
+	}
+	return gc.history.closest(loader.cacheKind, c.Param("key"), at)
+}
+
+func (gc graphController) getEpicGraph(c *gin.Context) {
+	gc.getGraph(c, epicGraphs)
 }
 
 func (gc graphController) getMilestoneGraph(c *gin.Context) {
-	epics, err := getMilestoneEpics(gc.jc, c.Param("key"))
+	gc.getGraph(c, milestoneGraphs)
+}
+
+func (gc graphController) getGraph(c *gin.Context, loader graphLoader) {
+	resp, err := gc.loadGraph(c, loader)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"status": http.StatusText(http.StatusInternalServerError)})
+		respondWithError(c, err)
 		return
 	}
-	if len(epics) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"status": http.StatusText(http.StatusNotFound)})
+	if err := gc.addForecast(c, &resp); err != nil {
+		respondWithError(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, resp)
+}
+
+type graphAnalyzer func(c *gin.Context, resp graphResponse) (interface{}, error)
 
-	epicKeys := make([]string, len(epics))
-	for i := range epics {
-		epicKeys[i] = epics[i].Key
+// rawResult lets a graph analysis respond with something other than JSON
+type rawResult struct {
+	contentType string
+	body        []byte
+}
+
+// graphAnalysis registers an analysis of epic and milestone graphs as a sub-resource of both, e.g.
+// '/api/epics/:key/<name>' and '/api/milestones/:key/<name>'
+func (gc graphController) graphAnalysis(r *gin.Engine, name string, analyze graphAnalyzer) {
+	gc.loaderAnalysis(r, name, func(graphLoader) graphAnalyzer { return analyze })
+}
+
+// loaderAnalysis registers a graph analysis like graphAnalysis, for analyses that load other graphs of the same kind
+// as the route's
+func (gc graphController) loaderAnalysis(r *gin.Engine, name string, analyzer func(graphLoader) graphAnalyzer) {
+	handler := func(loader graphLoader) gin.HandlerFunc {
+		analyze := analyzer(loader)
+		return func(c *gin.Context) {
+			resp, err := gc.loadGraph(c, loader)
+			if err != nil {
+				respondWithError(c, err)
+				return
+			}
+			result, err := analyze(c, resp)
+			if err != nil {
+				respondWithError(c, err)
+				return
+			}
+			if raw, ok := result.(rawResult); ok {
+				c.Data(http.StatusOK, raw.contentType, raw.body)
+				return
+			}
+			c.JSON(http.StatusOK, result)
+		}
 	}
+	r.GET("/api/epics/:key/"+name, handler(epicGraphs))
+	r.GET("/api/milestones/:key/"+name, handler(milestoneGraphs))
+}
 
-	issues, err := getIssues(gc.jc, epicKeys...)
+// planSprints proposes sprints for unscheduled work given the team's 'capacity' in points per sprint, which defaults
+// to the average velocity of the graph's board
+func (gc graphController) planSprints(c *gin.Context, resp graphResponse) (interface{}, error) {
+	capacity := 0.0
+	if raw, ok := c.GetQuery("capacity"); ok {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v <= 0 {
+			return nil, errInvalidQuery{"capacity must be a positive number"}
+		}
+		capacity = v
+	} else if boardID, ok := inferBoardID(resp.Issues); ok {
+		v, err := getBoardVelocity(gc.client(c), boardID, defaultVelocitySprints)
+		if err != nil {
+			return nil, err
+		}
+		capacity = v.Average
+	}
+	if capacity <= 0 {
+		return nil, errInvalidQuery{"capacity is required when there is no sprint history to derive it from"}
+	}
+	return planSprints(resp, capacity, time.Now()), nil
+}
+
+// analyzeDeadlines flags issues whose upstream work can't plausibly finish before their due date or release. The team
+// completes 'rate' points per day, by default its board's average velocity spread over the sprint length.
+func (gc graphController) analyzeDeadlines(c *gin.Context, resp graphResponse) (interface{}, error) {
+	rate := 0.0
+	if _, ok := c.GetQuery("rate"); ok {
+		if err := positiveFloatQuery(c, "rate", &rate); err != nil {
+			return nil, err
+		}
+	} else if boardID, ok := inferBoardID(resp.Issues); ok {
+		v, err := getBoardVelocity(gc.client(c), boardID, defaultVelocitySprints)
+		if err != nil {
+			return nil, err
+		}
+		sprints := make([]sprint, len(v.Sprints))
+		for i := range v.Sprints {
+			sprints[i] = v.Sprints[i].Sprint
+		}
+		rate = v.Average / (medianSprintLength(sprints).Hours() / 24)
+	}
+	if !isPositiveFinite(rate) {
+		return nil, errInvalidQuery{"rate is required when there is no sprint history to derive it from"}
+	}
+
+	issues, err := resolveReleaseDates(gc.client(c), resp.Issues)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"status": http.StatusText(http.StatusInternalServerError)})
-		return
+		return nil, err
 	}
+	resp.Issues = issues
+	return analyzeDeadlines(resp, rate, time.Now()), nil
+}
 
-	if len(issues) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"status": http.StatusText(http.StatusNotFound)})
-		return
+// diffGraph compares the graph with how the same kind of graph was at 'since', as stored or reconstructed by graphAt.
+// '?format=markdown' renders a summary.
+func (gc graphController) diffGraph(loader graphLoader) graphAnalyzer {
+	return func(c *gin.Context, resp graphResponse) (interface{}, error) {
+		raw, ok := c.GetQuery("since")
+		if !ok {
+			return nil, errInvalidQuery{"since is required"}
+		}
+		since, err := parseAt(raw)
+		if err != nil {
+			return nil, errInvalidQuery{"since must be a date (2006-01-02) or an RFC 3339 timestamp"}
+		}
+		before, err := gc.graphAt(c, loader, since)
+		if err != nil {
+			return nil, err
+		}
+
+		d := diffGraphs(before, resp, *before.SnapshotAt, resp.shownAt())
+		switch c.Query("format") {
+		case "", "json":
+			return d, nil
+		case "markdown":
+			return rawResult{contentType: "text/markdown; charset=utf-8", body: []byte(d.markdown(c.Param("key")))}, nil
+		}
+		return nil, errInvalidQuery{"format must be json or markdown"}
 	}
+}
 
-	resp := graphResponse{
-		Issues: issues,
-		Graph:  issuesToBlocksGraph(issues),
+// flowMetrics measures cycle, lead, flagged and blocked time of the graph's issues from their changelogs, as of the
+// time the graph shows
+func (gc graphController) flowMetrics(c *gin.Context, resp graphResponse) (interface{}, error) {
+	histories, err := searchGraphHistories(gc.client(c), resp)
+	if err != nil {
+		return nil, err
 	}
-	c.JSON(http.StatusOK, resp)
+	epicNames := map[string]string{}
+	for _, iss := range resp.Issues {
+		epicNames[iss.EpicKey] = iss.EpicName
+	}
+	return computeFlowMetrics(histories, histories.matcher(gc.jc.fieldConfig.Flagged), epicNames, resp.shownAt()), nil
+}
+
+// cumulativeFlow reconstructs the graph's daily issues and points per status category from changelogs, up to the time
+// the graph shows
+func (gc graphController) cumulativeFlow(c *gin.Context, resp graphResponse) (interface{}, error) {
+	histories, err := searchGraphHistories(gc.client(c), resp)
+	if err != nil {
+		return nil, err
+	}
+	return computeCumulativeFlow(histories, histories.estimateSource(gc.jc.fieldConfig), resp.shownAt()), nil
+}
+
+// scheduleGraph simulates who works on what and when, either with the issues' assignees or with a number of anonymous
+// 'developers', each completing 'rate' points per day. '?format=mermaid' renders a gantt chart.
+func scheduleGraph(c *gin.Context, resp graphResponse) (interface{}, error) {
+	opts, err := scheduleQuery(c)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := simulateSchedule(resp, opts, time.Now())
+	if err != nil {
+		return nil, errInvalidQuery{err.Error()}
+	}
+	switch c.Query("format") {
+	case "", "json":
+		return s, nil
+	case "mermaid":
+		return rawResult{contentType: "text/plain; charset=utf-8", body: []byte(s.mermaidGantt(c.Param("key") + " schedule"))}, nil
+	}
+	return nil, errInvalidQuery{"format must be json or mermaid"}
+}
+
+func scheduleQuery(c *gin.Context) (scheduleOptions, error) {
+	opts := scheduleOptions{rate: 1}
+	if err := boundedIntQuery(c, "developers", maxScheduleDevelopers, &opts.developers); err != nil {
+		return opts, err
+	}
+	if err := positiveFloatQuery(c, "rate", &opts.rate); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// timelineGraph places the graph's issues on a calendar. The 'developers' and 'rate' parameters tune the schedule
+// used for unsprinted work, as for scheduleGraph.
+func timelineGraph(c *gin.Context, resp graphResponse) (timeline, error) {
+	opts, err := scheduleQuery(c)
+	if err != nil {
+		return timeline{}, err
+	}
+	t, err := buildTimeline(resp, opts, time.Now())
+	if err != nil {
+		return timeline{}, errInvalidQuery{err.Error()}
+	}
+	return t, nil
+}
+
+// addForecast simulates completion dates when the request asks for a forecast, e.g. '?forecast=throughput'. A
+// velocity forecast uses the history of the given 'board' rather than the sprints seen in the graph.
+func (gc graphController) addForecast(c *gin.Context, resp *graphResponse) error {
+	method := c.Query("forecast")
+	if len(method) == 0 {
+		return nil
+	}
+	trials, history, board := defaultForecastTrials, defaultForecastHistory, 0
+	if err := boundedIntQuery(c, "trials", maxForecastTrials, &trials); err != nil {
+		return err
+	}
+	if err := boundedIntQuery(c, "history", maxForecastHistory, &history); err != nil {
+		return err
+	}
+	if err := positiveIntQuery(c, "board", &board); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var h forecastHistory
+	if method == forecastVelocity && board > 0 {
+		v, err := getBoardVelocity(gc.client(c), board, history)
+		if err != nil {
+			return err
+		}
+		h = v.history()
+	} else {
+		var err error
+		h, err = graphHistory(resp.Issues, method, history, now)
+		if err != nil {
+			return errInvalidQuery{err.Error()}
+		}
+	}
+
+	rng := rand.New(rand.NewSource(now.UnixNano()))
+	f, err := runForecast(resp.Issues, h, trials, now, rng)
+	if err != nil {
+		return errInvalidQuery{err.Error()}
+	}
+	f.Method = method
+	resp.Forecast = &f
+	return nil
+}
+
+// errInvalidQuery is a request that can't be answered as asked, reported to the client as a 400
+type errInvalidQuery struct {
+	msg string
+}
+
+func (e errInvalidQuery) Error() string {
+	return e.msg
+}
+
+// positiveIntQuery overrides dst with the named query parameter, if it is present
+func positiveIntQuery(c *gin.Context, name string, dst *int) error {
+	raw, ok := c.GetQuery(name)
+	if !ok {
+		return nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v <= 0 {
+		return errInvalidQuery{fmt.Sprintf("%s must be a positive integer", name)}
+	}
+	*dst = v
+	return nil
+}
+
+// positiveFloatQuery overrides dst with the named query parameter, if it is present
+func positiveFloatQuery(c *gin.Context, name string, dst *float64) error {
+	raw, ok := c.GetQuery(name)
+	if !ok {
+		return nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || !isPositiveFinite(v) {
+		return errInvalidQuery{fmt.Sprintf("%s must be a positive number", name)}
+	}
+	*dst = v
+	return nil
+}
+
+func isPositiveFinite(v float64) bool {
+	return v > 0 && !math.IsInf(v, 1)
+}
+
+// boundedIntQuery overrides dst with the named query parameter, if it is present and no greater than max
+func boundedIntQuery(c *gin.Context, name string, max int, dst *int) error {
+	v := *dst
+	if err := positiveIntQuery(c, name, &v); err != nil {
+		return err
+	}
+	if v > max {
+		return errInvalidQuery{fmt.Sprintf("%s must be at most %d", name, max)}
+	}
+	*dst = v
+	return nil
+}
+
+// respondWithError maps Jira lookup failures to a response status. Jira answers JQL that references a
+// non-existent key with a 400, so that is treated the same as an empty result.
+func respondWithError(c *gin.Context, err error) {
+	if eiq, ok := err.(errInvalidQuery); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"status": http.StatusText(http.StatusBadRequest), "error": eiq.msg})
+		return
+	}
+	ebs, ok := err.(errBadStatus)
+	if ok && (ebs.statusCode == http.StatusNotFound || ebs.statusCode == http.StatusBadRequest) {
+		c.JSON(http.StatusNotFound, gin.H{"status": http.StatusText(http.StatusNotFound)})
+		return
+	}
+	log.Printf("jira request failed: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"status": http.StatusText(http.StatusInternalServerError)})
 }
 
 type issueResponse struct {
 	JiraHost string `json:"jiraHost"`
 	Issue    issue  `json:"issue"`
+	staleness
 }
 
 func (gc graphController) getIssue(c *gin.Context) {
 	key := c.Param("key")
-	issue, err := getSingleIssue(gc.jc, key)
+	issue, s, err := gc.fetchIssue(gc.client(c), key)
 	if err != nil {
-		ebs, ok := err.(errBadStatus)
-		if ok {
-			if ebs.statusCode == http.StatusNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"status": http.StatusText(http.StatusNotFound)})
-				return
-			}
-		}
-		c.AbortWithError(http.StatusInternalServerError, err)
+		respondWithError(c, err)
 		return
 	}
 
@@ -150,21 +586,38 @@ func (gc graphController) getIssue(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, issueResponse{JiraHost: gc.jc.host, Issue: issue})
+	c.JSON(http.StatusOK, issueResponse{JiraHost: gc.jc.host, Issue: issue, staleness: s})
+}
+
+// fetchIssue looks up an issue in Jira, falling back to the cache while Jira is unavailable
+func (gc graphController) fetchIssue(jc jiraClient, key string) (issue, staleness, error) {
+	if gc.cache == nil {
+		iss, err := getSingleIssue(jc, key)
+		return iss, staleness{}, err
+	}
+	var iss issue
+	s, err := gc.cache.cached("issues", key, &iss, func() (interface{}, error) {
+		return getSingleIssue(jc.detached(), key)
+	})
+	return iss, s, err
 }
 
 func (gc graphController) redirectToJIRA(c *gin.Context) {
 	key := c.Param("key")
+	c.Redirect(http.StatusFound, browseURL(gc.jc.host, key))
+}
+
+func browseURL(jiraHost, key string) string {
 	u := url.URL{
 		Scheme: "https",
-		Host:   gc.jc.host,
+		Host:   jiraHost,
 		Path:   path.Join("browse", key),
 	}
-	c.Redirect(http.StatusFound, u.String())
+	return u.String()
 }
 
 func (gc graphController) getRelatedIssues(c *gin.Context) {
-	issues, err := getRelatedIssues(gc.jc, c.Param("key"))
+	issues, err := getRelatedIssues(gc.client(c), c.Param("key"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": http.StatusText(http.StatusInternalServerError)})
 		return
@@ -172,3 +625,60 @@ func (gc graphController) getRelatedIssues(c *gin.Context) {
 	//TODO: handle a non existent-requested epic as a 404
 	c.JSON(http.StatusOK, issues)
 }
+
+func (gc graphController) getSnapshot(c *gin.Context) {
+	key := c.Param("key")
+	opts := SnapshotOptions{Redact: c.Query("redact") == "true"}
+
+	var buf bytes.Buffer
+	if err := writeSnapshot(gc.client(c), key, opts, &buf); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.html"`, key))
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+func (gc graphController) getReport(c *gin.Context) {
+	data, err := getReportData(gc.client(c), c.Param("key"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := writeMarkdownReport(&buf, data); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", buf.Bytes())
+}
+
+func (gc graphController) getBoardVelocity(c *gin.Context) {
+	boardID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"status": http.StatusText(http.StatusNotFound)})
+		return
+	}
+	n := defaultVelocitySprints
+	if err := positiveIntQuery(c, "sprints", &n); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	v, err := getBoardVelocity(gc.client(c), boardID, n)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, v)
+}
+
+func (gc graphController) getEpicBurnup(c *gin.Context) {
+	b, err := getEpicBurnup(gc.client(c), c.Param("key"), time.Now())
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, b)
+}
@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func queryContext(rawQuery string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/?"+rawQuery, nil)
+	return c
+}
+
+func Test_boundedIntQuery(t *testing.T) {
+	trials := defaultForecastTrials
+	assert.NoError(t, boundedIntQuery(queryContext(""), "trials", maxForecastTrials, &trials))
+	assert.Equal(t, defaultForecastTrials, trials)
+
+	assert.NoError(t, boundedIntQuery(queryContext("trials=500"), "trials", maxForecastTrials, &trials))
+	assert.Equal(t, 500, trials)
+
+	assert.Equal(t, errInvalidQuery{"trials must be at most 100000"}, boundedIntQuery(queryContext("trials=2000000000"), "trials", maxForecastTrials, &trials))
+	assert.Equal(t, errInvalidQuery{"trials must be a positive integer"}, boundedIntQuery(queryContext("trials=0"), "trials", maxForecastTrials, &trials))
+	assert.Equal(t, 500, trials)
+}
+
+func Test_scheduleQuery(t *testing.T) {
+	opts, err := scheduleQuery(queryContext("developers=3&rate=2"))
+	assert.NoError(t, err)
+	assert.Equal(t, scheduleOptions{developers: 3, rate: 2}, opts)
+
+	_, err = scheduleQuery(queryContext("developers=100000000"))
+	assert.Equal(t, errInvalidQuery{"developers must be at most 500"}, err)
+	// timelines are scheduled with the same options
+	_, err = timelineGraph(queryContext("developers=100000000"), graphResponse{})
+	assert.Equal(t, errInvalidQuery{"developers must be at most 500"}, err)
+
+	for _, rate := range []string{"0", "-1", "NaN", "Inf", "abc"} {
+		_, err = scheduleQuery(queryContext("rate=" + rate))
+		assert.Equal(t, errInvalidQuery{"rate must be a positive number"}, err, rate)
+	}
+	_, err = timelineGraph(queryContext("rate=1e-300"), testGraph())
+	assert.IsType(t, errInvalidQuery{}, err)
+}
+
+func Test_analyzeDeadlinesRate(t *testing.T) {
+	for _, rate := range []string{"0", "NaN", "+Inf"} {
+		_, err := graphController{}.analyzeDeadlines(queryContext("rate="+rate), testGraph())
+		assert.Equal(t, errInvalidQuery{"rate must be a positive number"}, err, rate)
+	}
+}
+
+func Test_diffGraph(t *testing.T) {
+	gc := graphController{history: &historyStore{dir: t.TempDir()}}
+	since := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, gc.history.save(milestoneGraphs.cacheKind, "JG-10", since, testGraph()))
+
+	c := queryContext("since=2026-09-01")
+	c.Params = gin.Params{{Key: "key", Value: "JG-10"}}
+	result, err := gc.diffGraph(milestoneGraphs)(c, testGraph())
+	if assert.NoError(t, err) {
+		assert.True(t, result.(graphDiff).empty())
+	}
+	// the milestone's snapshot isn't compared with its epic graph
+	_, err = gc.diffGraph(epicGraphs)(c, testGraph())
+	assert.Equal(t, errBadStatus{404}, err)
+}
+
+func Test_shownAt(t *testing.T) {
+	at := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, at, graphResponse{SnapshotAt: &at}.shownAt())
+	assert.WithinDuration(t, time.Now(), graphResponse{}.shownAt(), time.Minute)
+}
@@ -0,0 +1,154 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// ShowOptions customizes the terminal rendering of a graph
+type ShowOptions struct {
+	// Upstream limits the rendering to the given issue and everything that transitively blocks it
+	Upstream string
+	// ASCII avoids Unicode glyphs for terminals that can't render them
+	ASCII bool
+}
+
+type glyphSet struct {
+	backlog, inProgress, resolved, closed, unknown, flagged, arrow, ellipsis string
+}
+
+var (
+	unicodeGlyphs = glyphSet{backlog: "○", inProgress: "◐", resolved: "◕", closed: "●", unknown: "?", flagged: "⚑", arrow: "←", ellipsis: "…"}
+	asciiGlyphs   = glyphSet{backlog: "[ ]", inProgress: "[~]", resolved: "[+]", closed: "[x]", unknown: "[?]", flagged: "!", arrow: "<-", ellipsis: "..."}
+)
+
+func (g glyphSet) status(s string) string {
+	switch categorizeStatus(s) {
+	case statusBacklog:
+		return g.backlog
+	case statusInProgress:
+		return g.inProgress
+	case statusResolvedOnStaging:
+		return g.resolved
+	case statusClosed:
+		return g.closed
+	}
+	return g.unknown
+}
+
+// Show fetches the graph for an epic or milestone key and prints it to w as layered text
+func Show(user, pass, jiraHost string, fc FieldConfig, key string, opts ShowOptions, w io.Writer) error {
+	useStatusCategories(fc)
+	jc := jiraClient{
+		host:        jiraHost,
+		user:        user,
+		pass:        pass,
+		fieldConfig: fc,
+	}
+	resp, err := getKeyGraph(jc, key)
+	if err != nil {
+		return err
+	}
+	if len(opts.Upstream) > 0 {
+		resp = filterGraph(resp, upstreamKeys(resp.Issues, opts.Upstream))
+		if len(resp.Issues) == 0 {
+			return fmt.Errorf("%s is not part of the %s graph", opts.Upstream, key)
+		}
+	}
+	return renderText(w, resp, opts)
+}
+
+// issueLayers assigns each issue to the length of the longest chain of in-graph blockers preceding it. Issues on a
+// cycle are placed as if the back edge didn't exist.
+func issueLayers(issues []issue) map[string]int {
+	byKey := make(map[string]issue, len(issues))
+	for _, iss := range issues {
+		byKey[iss.Key] = iss
+	}
+
+	layers := map[string]int{}
+	visiting := map[string]bool{}
+	var layerOf func(key string) int
+	layerOf = func(key string) int {
+		if l, ok := layers[key]; ok {
+			return l
+		}
+		visiting[key] = true
+		layer := 0
+		for _, blockedBy := range byKey[key].blockedByKeys {
+			if _, inGraph := byKey[blockedBy]; !inGraph || visiting[blockedBy] {
+				continue
+			}
+			if l := layerOf(blockedBy) + 1; l > layer {
+				layer = l
+			}
+		}
+		visiting[key] = false
+		layers[key] = layer
+		return layer
+	}
+
+	for _, iss := range issues {
+		layerOf(iss.Key)
+	}
+	return layers
+}
+
+func renderText(w io.Writer, resp graphResponse, opts ShowOptions) error {
+	glyphs := unicodeGlyphs
+	if opts.ASCII {
+		glyphs = asciiGlyphs
+	}
+
+	layers := issueLayers(resp.Issues)
+	byLayer := map[int][]issue{}
+	maxLayer := 0
+	for _, iss := range resp.Issues {
+		l := layers[iss.Key]
+		byLayer[l] = append(byLayer[l], iss)
+		if l > maxLayer {
+			maxLayer = l
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for l := 0; l <= maxLayer; l++ {
+		layer := byLayer[l]
+		sort.Slice(layer, func(i, j int) bool { return layer[i].Key < layer[j].Key })
+		fmt.Fprintf(tw, "Layer %d\n", l)
+		for _, iss := range layer {
+			flag := ""
+			if iss.Flagged {
+				flag = glyphs.flagged
+			}
+			blockers := ""
+			if len(iss.blockedByKeys) > 0 {
+				sorted := append([]string{}, iss.blockedByKeys...)
+				sort.Strings(sorted)
+				blockers = glyphs.arrow + " " + strings.Join(sorted, ", ")
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				glyphs.status(iss.Status),
+				iss.Key,
+				flag,
+				strconv.FormatFloat(iss.Estimate, 'f', -1, 64),
+				truncate(iss.Summary, 50, glyphs.ellipsis),
+				iss.Assignee,
+				blockers,
+			)
+		}
+	}
+	return tw.Flush()
+}
+
+func truncate(s string, max int, ellipsis string) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-len([]rune(ellipsis))]) + ellipsis
+}
@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_issueLayers(t *testing.T) {
+	issues := []issue{
+		{Key: "JG-1"},
+		{Key: "JG-2", blockedByKeys: []string{"JG-1"}},
+		{Key: "JG-3", blockedByKeys: []string{"JG-1", "JG-2", "OTHER-1"}},
+		// JG-4 and JG-5 block each other
+		{Key: "JG-4", blockedByKeys: []string{"JG-5"}},
+		{Key: "JG-5", blockedByKeys: []string{"JG-4"}},
+	}
+	assert.Equal(t, map[string]int{"JG-1": 0, "JG-2": 1, "JG-3": 2, "JG-4": 1, "JG-5": 0}, issueLayers(issues))
+}
+
+func Test_renderText(t *testing.T) {
+	issues := []issue{
+		{Key: "JG-2", Status: "In Progress", Estimate: 1.5, Summary: "Second", Assignee: "Bo", blockedByKeys: []string{"JG-1"}},
+		{Key: "JG-1", Status: "Closed", Estimate: 3, Summary: "First", Flagged: true},
+	}
+	resp := graphResponse{Issues: issues, Graph: issuesToBlocksGraph(issues)}
+
+	var buf bytes.Buffer
+	assert.NoError(t, renderText(&buf, resp, ShowOptions{ASCII: true}))
+	// columns are aligned within each layer
+	assert.Equal(t, "Layer 0\n"+
+		"  [x]  JG-1  !  3  First    \n"+
+		"Layer 1\n"+
+		"  [~]  JG-2    1.5  Second  Bo  <- JG-1\n", buf.String())
+}
+
+func Test_truncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 5, "…"))
+	assert.Equal(t, "long…", truncate("longer", 5, "…"))
+	assert.Equal(t, "lo...", truncate("longer", 5, "..."))
+	// multi-byte runes count as one and aren't split
+	assert.Equal(t, "日本語", truncate("日本語", 3, "…"))
+	assert.Equal(t, "日本…", truncate("日本語です", 3, "…"))
+}
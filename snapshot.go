@@ -0,0 +1,202 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// SnapshotOptions customizes a static HTML snapshot
+type SnapshotOptions struct {
+	// Redact replaces assignee, epic, label, fix version and sprint names with stable placeholders and blanks out issue
+	// summaries
+	Redact bool
+}
+
+// Snapshot writes a single self-contained HTML file to w that renders the graph for an epic or milestone key offline,
+// by bundling the embedded SPA assets with frozen API responses
+func Snapshot(user, pass, jiraHost string, fc FieldConfig, key string, opts SnapshotOptions, w io.Writer) error {
+	useStatusCategories(fc)
+	jc := jiraClient{
+		host:        jiraHost,
+		user:        user,
+		pass:        pass,
+		fieldConfig: fc,
+	}
+	return writeSnapshot(jc, key, opts, w)
+}
+
+// snapshotData is read by src/snapshot.ts in place of API calls
+type snapshotData struct {
+	IssueKey  string                 `json:"issueKey"`
+	Responses map[string]interface{} `json:"responses"`
+}
+
+func writeSnapshot(jc jiraClient, key string, opts SnapshotOptions, w io.Writer) error {
+	iss, err := getSingleIssue(jc, key)
+	if err != nil {
+		return err
+	}
+
+	var graphURI string
+	var resp graphResponse
+	if iss.Type == "Milestone" {
+		graphURI = "/api/milestones/" + key
+		resp, err = getMilestoneGraph(jc, key)
+	} else {
+		graphURI = "/api/epics/" + key
+		resp, err = getEpicGraph(jc, key)
+	}
+	if err != nil {
+		return err
+	}
+	related, err := getRelatedIssues(jc, key)
+	if err != nil {
+		return err
+	}
+
+	if opts.Redact {
+		r := newRedactor()
+		iss = r.redact(iss)
+		resp.Issues = r.redactAll(resp.Issues)
+		related = r.redactAll(related)
+	}
+
+	data := snapshotData{
+		IssueKey: key,
+		Responses: map[string]interface{}{
+			"/api/issues/" + key:              issueResponse{JiraHost: jc.host, Issue: iss},
+			"/api/issues/" + key + "/related": related,
+			graphURI:                          resp,
+		},
+	}
+	// encoding/json escapes '<' and '>', so the payload can't close the script tag it is embedded in
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	page, err := inlinedIndexHTML()
+	if err != nil {
+		return err
+	}
+	dataScript := fmt.Sprintf("<script>window.jiraGraphSnapshot = %s;</script>\n</head>", dataJSON)
+	page = strings.Replace(page, "</head>", dataScript, 1)
+	_, err = io.WriteString(w, page)
+	return err
+}
+
+// redactor hides people and content while keeping the shape of the graph. Names are replaced with placeholders that
+// stay the same across issues, e.g. every issue of an epic keeps the same epic name.
+type redactor struct {
+	aliases map[string]map[string]string
+}
+
+func newRedactor() *redactor {
+	return &redactor{aliases: map[string]map[string]string{}}
+}
+
+// alias returns the placeholder for a name of the given kind, such as 'Assignee 2'
+func (r *redactor) alias(kind, name string) string {
+	if len(name) == 0 {
+		return ""
+	}
+	names, ok := r.aliases[kind]
+	if !ok {
+		names = map[string]string{}
+		r.aliases[kind] = names
+	}
+	alias, ok := names[name]
+	if !ok {
+		alias = fmt.Sprintf("%s %d", kind, len(names)+1)
+		names[name] = alias
+	}
+	return alias
+}
+
+func (r *redactor) redact(iss issue) issue {
+	iss.Assignee = r.alias("Assignee", iss.Assignee)
+	iss.AssigneeImageURL = ""
+	iss.Summary = "[redacted]"
+	iss.EpicName = r.alias("Epic", iss.EpicName)
+
+	labels := make([]string, len(iss.Labels))
+	for i, l := range iss.Labels {
+		labels[i] = r.alias("Label", l)
+	}
+	iss.Labels = labels
+	versions := make([]fixVersion, len(iss.FixVersions))
+	for i, v := range iss.FixVersions {
+		v.Name = r.alias("Version", v.Name)
+		versions[i] = v
+	}
+	iss.FixVersions = versions
+	sprints := make([]sprint, len(iss.Sprints))
+	for i, s := range iss.Sprints {
+		s.Name = r.alias("Sprint", s.Name)
+		sprints[i] = s
+	}
+	iss.Sprints = sprints
+	return iss
+}
+
+func (r *redactor) redactAll(issues []issue) []issue {
+	result := make([]issue, len(issues))
+	for i := range issues {
+		result[i] = r.redact(issues[i])
+	}
+	return result
+}
+
+var (
+	assetScriptTag = regexp.MustCompile(`<script([^>]*) src="/assets/([^"]+)"([^>]*)></script>`)
+	assetLinkTag   = regexp.MustCompile(`<link([^>]*) href="/assets/([^"]+)"([^>]*)>`)
+	assetCSSURL    = regexp.MustCompile(`url\(["']?/assets/([^"')]+)["']?\)`)
+)
+
+// inlinedIndexHTML returns the embedded SPA entrypoint with its scripts and stylesheets inlined, so it doesn't need
+// the server to resolve '/assets'
+func inlinedIndexHTML() (string, error) {
+	index, err := distFS.ReadFile("dist/index.html")
+	if err != nil {
+		return "", err
+	}
+
+	var inlineErr error
+	readAsset := func(name string) []byte {
+		b, err := distFS.ReadFile(path.Join("dist/assets", name))
+		if err != nil && inlineErr == nil {
+			inlineErr = err
+		}
+		return b
+	}
+
+	page := assetScriptTag.ReplaceAllStringFunc(string(index), func(tag string) string {
+		m := assetScriptTag.FindStringSubmatch(tag)
+		js := bytes.ReplaceAll(readAsset(m[2]), []byte("</script"), []byte(`<\/script`))
+		return fmt.Sprintf("<script%s%s>%s</script>", m[1], m[3], js)
+	})
+	page = assetLinkTag.ReplaceAllStringFunc(page, func(tag string) string {
+		m := assetLinkTag.FindStringSubmatch(tag)
+		if !strings.Contains(tag, `rel="stylesheet"`) {
+			// preload hints are meaningless once everything is inline
+			return ""
+		}
+		css := assetCSSURL.ReplaceAllStringFunc(string(readAsset(m[2])), func(u string) string {
+			name := assetCSSURL.FindStringSubmatch(u)[1]
+			encoded := base64.StdEncoding.EncodeToString(readAsset(name))
+			return fmt.Sprintf("url(data:%s;base64,%s)", mime.TypeByExtension(path.Ext(name)), encoded)
+		})
+		return fmt.Sprintf("<style>%s</style>", css)
+	})
+	if inlineErr != nil {
+		return "", inlineErr
+	}
+	return page, nil
+}
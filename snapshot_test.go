@@ -0,0 +1,47 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_redactAll(t *testing.T) {
+	issues := []issue{
+		{
+			Key: "JG-2", Summary: "Secret plan", Assignee: "Alice", AssigneeImageURL: "https://example.com/alice.png",
+			EpicKey: "JG-1", EpicName: "Project Falcon", Labels: []string{"falcon", "backend"},
+			FixVersions: []fixVersion{{ID: "1", Name: "Falcon 1.0"}}, Sprints: []sprint{{ID: 7, Name: "Falcon sprint 3"}},
+		},
+		{Key: "JG-3", Assignee: "Bob", EpicKey: "JG-1", EpicName: "Project Falcon", Labels: []string{"backend"}},
+		{Key: "JG-4", Assignee: "Alice"},
+	}
+
+	redacted := newRedactor().redactAll(issues)
+	assert.Equal(t, issue{
+		Key: "JG-2", Summary: "[redacted]", Assignee: "Assignee 1",
+		EpicKey: "JG-1", EpicName: "Epic 1", Labels: []string{"Label 1", "Label 2"},
+		FixVersions: []fixVersion{{ID: "1", Name: "Version 1"}}, Sprints: []sprint{{ID: 7, Name: "Sprint 1"}},
+	}, redacted[0])
+	assert.Equal(t, "Assignee 2", redacted[1].Assignee)
+	assert.Equal(t, "Epic 1", redacted[1].EpicName)
+	assert.Equal(t, []string{"Label 2"}, redacted[1].Labels)
+	assert.Equal(t, "Assignee 1", redacted[2].Assignee)
+	assert.Empty(t, redacted[2].EpicName)
+
+	// the originals are left alone
+	assert.Equal(t, "Project Falcon", issues[0].EpicName)
+	assert.Equal(t, "falcon", issues[0].Labels[0])
+}
+
+func Test_inlinedIndexHTML(t *testing.T) {
+	page, err := inlinedIndexHTML()
+	assert.NoError(t, err)
+	assert.NotContains(t, page, `src="/assets/`)
+	assert.NotContains(t, page, `href="/assets/`)
+
+	js, err := distFS.ReadFile("dist/assets/index.js")
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(page, strings.ReplaceAll(string(js), "</script", `<\/script`)))
+}
@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	findingNotAfterBlocker  = "scheduledNotAfterBlocker"
+	findingBlockerInBacklog = "activeBlockedByBacklog"
+)
+
+type sprintFinding struct {
+	Kind          string  `json:"kind"`
+	Blocker       string  `json:"blocker"`
+	Blocked       string  `json:"blocked"`
+	BlockerSprint *sprint `json:"blockerSprint,omitempty"`
+	BlockedSprint *sprint `json:"blockedSprint,omitempty"`
+	Message       string  `json:"message"`
+}
+
+type sprintCheck struct {
+	Findings []sprintFinding `json:"findings"`
+}
+
+func isActiveSprint(s sprint) bool {
+	return strings.EqualFold(s.State, "active")
+}
+
+// isOpenSprint is an active or future sprint, i.e. one that work can still be scheduled into
+func isOpenSprint(s sprint) bool {
+	return isActiveSprint(s) || strings.EqualFold(s.State, "future")
+}
+
+// scheduledSprint is the open sprint an issue is currently planned in; issues whose latest sprint has closed are back
+// in the backlog
+func scheduledSprint(iss issue) (sprint, bool) {
+	if len(iss.Sprints) == 0 {
+		return sprint{}, false
+	}
+	latest := iss.Sprints[len(iss.Sprints)-1]
+	return latest, isOpenSprint(latest)
+}
+
+// sprintBefore orders sprints by sequence when both have one, falling back to start dates and then IDs
+func sprintBefore(a, b sprint) bool {
+	if a.Sequence > 0 && b.Sequence > 0 && a.Sequence != b.Sequence {
+		return a.Sequence < b.Sequence
+	}
+	if !a.StartDate.IsZero() && !b.StartDate.IsZero() && !a.StartDate.Equal(b.StartDate) {
+		return a.StartDate.Before(b.StartDate)
+	}
+	if isActiveSprint(a) != isActiveSprint(b) {
+		return isActiveSprint(a)
+	}
+	return a.ID < b.ID
+}
+
+// checkSprintPlan flags every unfinished blocks edge where the blocked issue is scheduled into the same sprint as its
+// blocker or an earlier one, and every issue in an active sprint whose unfinished blockers aren't scheduled at all.
+// Edges to issues outside of the graph can't be checked and are skipped.
+func checkSprintPlan(resp graphResponse) sprintCheck {
+	byKey := make(map[string]issue, len(resp.Issues))
+	for _, iss := range resp.Issues {
+		byKey[iss.Key] = iss
+	}
+
+	findings := []sprintFinding{}
+	for _, e := range sortedEdges(resp.Graph) {
+		blocker, blockerOK := byKey[e[0]]
+		blocked, blockedOK := byKey[e[1]]
+		if !blockerOK || !blockedOK || isDone(blocker) || isDone(blocked) {
+			continue
+		}
+		blockedSprint, blockedScheduled := scheduledSprint(blocked)
+		if !blockedScheduled {
+			continue
+		}
+
+		blockerSprint, blockerScheduled := scheduledSprint(blocker)
+		switch {
+		case blockerScheduled && !sprintBefore(blockerSprint, blockedSprint):
+			findings = append(findings, sprintFinding{
+				Kind:          findingNotAfterBlocker,
+				Blocker:       blocker.Key,
+				Blocked:       blocked.Key,
+				BlockerSprint: &blockerSprint,
+				BlockedSprint: &blockedSprint,
+				Message:       fmt.Sprintf("%s is scheduled in %s, no later than its blocker %s in %s", blocked.Key, blockedSprint.Name, blocker.Key, blockerSprint.Name),
+			})
+		case !blockerScheduled && isActiveSprint(blockedSprint):
+			findings = append(findings, sprintFinding{
+				Kind:          findingBlockerInBacklog,
+				Blocker:       blocker.Key,
+				Blocked:       blocked.Key,
+				BlockedSprint: &blockedSprint,
+				Message:       fmt.Sprintf("%s is in the active sprint %s, but its blocker %s is still in the backlog", blocked.Key, blockedSprint.Name, blocker.Key),
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Blocked < findings[j].Blocked })
+	return sprintCheck{Findings: findings}
+}
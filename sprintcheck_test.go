@@ -0,0 +1,34 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkSprintPlan(t *testing.T) {
+	active := sprint{ID: 1, State: "active", Name: "Sprint 1", Sequence: 1}
+	future := sprint{ID: 2, State: "future", Name: "Sprint 2", Sequence: 2}
+	closed := sprint{ID: 0, State: "closed", Name: "Sprint 0"}
+	issues := []issue{
+		{Key: "JG-1", Status: "Backlog", Sprints: []sprint{future}},
+		{Key: "JG-2", Status: "Backlog", Sprints: []sprint{active}, blockedByKeys: []string{"JG-1"}},
+		{Key: "JG-3", Status: "Backlog", Sprints: []sprint{closed}},
+		{Key: "JG-4", Status: "In Progress", Sprints: []sprint{active}, blockedByKeys: []string{"JG-3"}},
+		{Key: "JG-5", Status: "Backlog", Sprints: []sprint{future}, blockedByKeys: []string{"JG-2"}},
+		{Key: "JG-6", Status: "Closed", Sprints: []sprint{future}},
+		{Key: "JG-7", Status: "Backlog", Sprints: []sprint{active}, blockedByKeys: []string{"JG-6"}},
+	}
+	resp := graphResponse{Issues: issues, Graph: issuesToBlocksGraph(issues)}
+
+	findings := checkSprintPlan(resp).Findings
+	if assert.Len(t, findings, 2) {
+		assert.Equal(t, findingNotAfterBlocker, findings[0].Kind)
+		assert.Equal(t, "JG-1", findings[0].Blocker)
+		assert.Equal(t, "JG-2", findings[0].Blocked)
+
+		assert.Equal(t, findingBlockerInBacklog, findings[1].Kind)
+		assert.Equal(t, "JG-3", findings[1].Blocker)
+		assert.Equal(t, "JG-4", findings[1].Blocked)
+	}
+}
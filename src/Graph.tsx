@@ -3,6 +3,7 @@ import React from 'react';
 import { useParams } from 'react-router-dom';
 import { pushRecentIssue } from './recent';
 import { colors } from './colors';
+import { apiFetch } from './snapshot';
 import './graph.css';
 
 interface FullIssue {
@@ -23,6 +24,10 @@ interface FullIssue {
     color: keyof typeof colors;
     epicKey: string;
     epicName: string;
+    estimateUnit: 'points' | 'hours';
+    remainingHours: number;
+    loggedHours: number;
+    originalHours: number;
 }
 
 type IssueGraphType = { issues: FullIssue[]; graph: Record<string, string[]> };
@@ -259,13 +264,7 @@ class GraphApp extends React.Component<GraphAppProps, GraphAppState> {
         console.log(`loading ${issueKey}`);
         const uriPrefix = this.props.issueType === 'Milestone' ? '/api/milestones/' : '/api/epics/';
 
-        fetch(uriPrefix + issueKey)
-            .then((res) => {
-                if (!res.ok) {
-                    throw new Error('not ok');
-                }
-                return res.json();
-            })
+        apiFetch(uriPrefix + issueKey)
             .then((result) => {
                 this.setState({
                     isLoaded: true,
@@ -368,13 +367,7 @@ class RelatedIssues extends React.Component<{ issueKey: string }, RelatedIssuesS
         const issueKey = this.props.issueKey;
 
         console.log(`loading related issues for ${issueKey}`);
-        fetch(`/api/issues/${issueKey}/related`)
-            .then((res) => {
-                if (!res.ok) {
-                    throw new Error('not ok');
-                }
-                return res.json();
-            })
+        apiFetch(`/api/issues/${issueKey}/related`)
             .then((result) => {
                 this.setState({
                     isLoaded: true,
@@ -695,13 +688,7 @@ class IssueGraph extends React.Component<{ issueKey?: string }, IssueGraphState>
         const issueKey = this.props.issueKey;
 
         console.log(`loading related issues for ${issueKey}`);
-        fetch(`/api/issues/${issueKey}`)
-            .then((res) => {
-                if (!res.ok) {
-                    throw new Error('not ok');
-                }
-                return res.json();
-            })
+        apiFetch(`/api/issues/${issueKey}`)
             .then((result) => {
                 this.setState((prevState) => ({
                     ...prevState,
@@ -738,6 +725,7 @@ class IssueGraph extends React.Component<{ issueKey?: string }, IssueGraphState>
 class EpicStats extends React.Component<{ initialEstimate: number; issueGraph: IssueGraphType }> {
     render() {
         const byStatus = this.getBreakdownByStatus();
+        const unitLabel = this.props.issueGraph.issues[0]?.estimateUnit === 'hours' ? 'Hour' : 'Point';
         const initialEstimateRow =
             this.props.initialEstimate !== 0 ? (
                 <tr className='initialEstimate'>
@@ -780,7 +768,7 @@ class EpicStats extends React.Component<{ initialEstimate: number; issueGraph: I
                 <table>
                     <thead>
                         <tr>
-                            <th colSpan={2}>Point Breakdown</th>
+                            <th colSpan={2}>{unitLabel} Breakdown</th>
                         </tr>
                     </thead>
                     <tbody>
@@ -1,27 +1,34 @@
 import React from 'react';
 import { render } from 'react-dom';
-import { BrowserRouter, Routes, Route, Outlet } from 'react-router-dom';
+import { BrowserRouter, MemoryRouter, Routes, Route, Outlet } from 'react-router-dom';
 import { IssueList } from './IssueList';
 import { RoutedIssueGraph } from './Graph';
 import { NotFound } from './Errors';
+import { snapshot } from './snapshot';
+
+const routes = (
+    <Routes>
+        <Route path='/' element={<IssueList />} />
+        <Route path='/index.html' element={<IssueList />} />
+        <Route
+            path='issues'
+            element={
+                <main>
+                    <Outlet />
+                </main>
+            }
+        >
+            <Route path=':issueKey' element={<RoutedIssueGraph />} />
+        </Route>
+        <Route path='*' element={<NotFound />} />
+    </Routes>
+);
 
 render(
-    <BrowserRouter>
-        <Routes>
-            <Route path='/' element={<IssueList />} />
-            <Route path='/index.html' element={<IssueList />} />
-            <Route
-                path='issues'
-                element={
-                    <main>
-                        <Outlet />
-                    </main>
-                }
-            >
-                <Route path=':issueKey' element={<RoutedIssueGraph />} />
-            </Route>
-            <Route path='*' element={<NotFound />} />
-        </Routes>
-    </BrowserRouter>,
+    snapshot !== undefined ? (
+        <MemoryRouter initialEntries={[`/issues/${snapshot.issueKey}`]}>{routes}</MemoryRouter>
+    ) : (
+        <BrowserRouter>{routes}</BrowserRouter>
+    ),
     document.getElementById('root'),
 );
@@ -0,0 +1,30 @@
+// A static HTML snapshot exported by `graphcmd snapshot` freezes the API responses for one issue into the page, so
+// that it can be viewed offline without the API server.
+interface Snapshot {
+    issueKey: string;
+    responses: Record<string, unknown>;
+}
+
+declare global {
+    interface Window {
+        jiraGraphSnapshot?: Snapshot;
+    }
+}
+
+export const snapshot = window.jiraGraphSnapshot;
+
+export const apiFetch = (uri: string): Promise<any> => {
+    if (snapshot !== undefined) {
+        const response = snapshot.responses[uri];
+        if (response === undefined) {
+            return Promise.reject(new Error(`${uri} is not part of this snapshot`));
+        }
+        return Promise.resolve(response);
+    }
+    return fetch(uri).then((res) => {
+        if (!res.ok) {
+            throw new Error('not ok');
+        }
+        return res.json();
+    });
+};
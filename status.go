@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The statuses of the default workflow. They mirror the ones in src/Graph.tsx.
+const (
+	statusBacklog           = "Backlog"
+	statusReadyForDev       = "Ready for Dev"
+	statusInProgress        = "In Progress"
+	statusOnFeatureBranch   = "In QA on feature branch"
+	statusInCodeReview      = "In Code Review"
+	statusResolvedOnStaging = "Resolved, on staging"
+	statusClosed            = "Closed"
+)
+
+// defaultStatusCategories collapses the default workflow's statuses into categories
+var defaultStatusCategories = map[string]string{
+	statusBacklog:         statusBacklog,
+	statusReadyForDev:     statusBacklog,
+	statusInProgress:      statusInProgress,
+	statusOnFeatureBranch: statusInProgress,
+	statusInCodeReview:    statusInProgress,
+}
+
+// statusCategories is the mapping in use, set from the FieldConfig by useStatusCategories
+var statusCategories = defaultStatusCategories
+
+// useStatusCategories makes categorizeStatus follow the configured mapping, or the default workflow's without one.
+// It's called once by each entry point, before any lookups.
+func useStatusCategories(fc FieldConfig) {
+	if fc.StatusCategories == nil {
+		statusCategories = defaultStatusCategories
+		return
+	}
+	statusCategories = fc.StatusCategories
+}
+
+// categorizeStatus collapses workflow statuses into the handful of buckets shown in the UI. Statuses without a
+// category are a bucket of their own.
+func categorizeStatus(s string) string {
+	if category, ok := statusCategories[s]; ok {
+		return category
+	}
+	return s
+}
+
+// ParseStatusCategories reads a status mapping given as 'Status=Category' pairs separated by semicolons, e.g.
+// 'To Do=Backlog;Doing=In Progress;Done=Closed'
+func ParseStatusCategories(raw string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, pair := range strings.Split(raw, ";") {
+		if len(strings.TrimSpace(pair)) == 0 {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 {
+			return nil, fmt.Errorf("%q is not a Status=Category pair", pair)
+		}
+		result[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return result, nil
+}
@@ -0,0 +1,39 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseStatusCategories(t *testing.T) {
+	categories, err := ParseStatusCategories("To Do=Backlog; Doing=In Progress;Done, deployed=Closed;")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"To Do": statusBacklog, "Doing": statusInProgress, "Done, deployed": statusClosed}, categories)
+
+	_, err = ParseStatusCategories("To Do")
+	assert.Error(t, err)
+	assert.Error(t, FieldConfig{StatusCategories: map[string]string{"To Do": "Todo"}}.Validate())
+}
+
+func Test_categorizeStatus(t *testing.T) {
+	t.Cleanup(func() { useStatusCategories(FieldConfig{}) })
+
+	assert.Equal(t, statusBacklog, categorizeStatus(statusReadyForDev))
+	assert.Equal(t, statusResolvedOnStaging, categorizeStatus(statusResolvedOnStaging))
+
+	useStatusCategories(FieldConfig{StatusCategories: map[string]string{"To Do": statusBacklog, "Doing": statusInProgress, "Done": statusClosed}})
+	assert.Equal(t, statusClosed, categorizeStatus("Done"))
+	assert.Equal(t, statusReadyForDev, categorizeStatus(statusReadyForDev))
+	assert.True(t, isDone(issue{Status: "Done"}))
+
+	day := func(d int) time.Time { return time.Date(2021, 10, d, 12, 0, 0, 0, time.UTC) }
+	histories := issueHistories{histories: []issueHistory{{
+		issue:   issue{Key: "JG-1", Status: "Done", Estimate: 3},
+		created: day(1),
+		changes: []change{{At: day(2), Field: "status", FromString: "To Do", ToString: "Done"}},
+	}}}
+	flow := computeCumulativeFlow(histories, estimateSource{perUnit: 1}, day(2))
+	assert.Equal(t, []string{statusBacklog, statusClosed}, flow.Categories)
+}
@@ -0,0 +1,135 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	placedBySprint   = "sprint"
+	placedBySchedule = "schedule"
+	placedByResolved = "resolved"
+)
+
+type timelineItem struct {
+	Key     string    `json:"key"`
+	Summary string    `json:"summary"`
+	Status  string    `json:"status"`
+	EpicKey string    `json:"epicKey"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	// PlacedBy is how the dates were derived: from the issue's sprint, from the simulated schedule, or from the
+	// resolution date of finished work
+	PlacedBy string `json:"placedBy"`
+	Sprint   string `json:"sprint,omitempty"`
+}
+
+type timelineEpic struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	// ProjectedEnd is the latest end of the epic's issues on the timeline
+	ProjectedEnd time.Time `json:"projectedEnd"`
+}
+
+type timeline struct {
+	Items []timelineItem `json:"items"`
+	// Dependencies are blocks edges between items on the timeline, blocker first
+	Dependencies [][2]string    `json:"dependencies"`
+	Epics        []timelineEpic `json:"epics"`
+}
+
+// buildTimeline dates every issue by its latest sprint when that sprint has dates, and is still open or the issue is
+// finished. Unfinished issues without such a sprint are back in the backlog and fall back to the schedule simulation,
+// and finished ones to their resolution date; finished issues with neither are left off.
+func buildTimeline(resp graphResponse, opts scheduleOptions, now time.Time) (timeline, error) {
+	s, err := simulateSchedule(resp, opts, now)
+	if err != nil {
+		return timeline{}, err
+	}
+	scheduled := map[string]scheduledIssue{}
+	for _, t := range s.Timelines {
+		for _, iss := range t.Issues {
+			scheduled[iss.Key] = iss
+		}
+	}
+
+	result := timeline{Items: []timelineItem{}, Dependencies: [][2]string{}, Epics: []timelineEpic{}}
+	onTimeline := map[string]bool{}
+	epics := map[string]*timelineEpic{}
+	for _, iss := range resp.Issues {
+		item := timelineItem{Key: iss.Key, Summary: iss.Summary, Status: iss.Status, EpicKey: iss.EpicKey}
+		if s, ok := datedSprint(iss); ok {
+			item.Start, item.End, item.PlacedBy, item.Sprint = s.StartDate, s.EndDate, placedBySprint, s.Name
+		} else if s, ok := scheduled[iss.Key]; ok {
+			item.Start, item.End, item.PlacedBy = s.Start, s.End, placedBySchedule
+		} else if !iss.Resolved.IsZero() {
+			item.Start, item.End, item.PlacedBy = iss.Resolved, iss.Resolved, placedByResolved
+		} else {
+			continue
+		}
+		result.Items = append(result.Items, item)
+		onTimeline[iss.Key] = true
+
+		if len(iss.EpicKey) == 0 {
+			continue
+		}
+		e, ok := epics[iss.EpicKey]
+		if !ok {
+			e = &timelineEpic{Key: iss.EpicKey, Name: iss.EpicName}
+			epics[iss.EpicKey] = e
+		}
+		if item.End.After(e.ProjectedEnd) {
+			e.ProjectedEnd = item.End
+		}
+	}
+
+	for _, e := range sortedEdges(resp.Graph) {
+		if onTimeline[e[0]] && onTimeline[e[1]] {
+			result.Dependencies = append(result.Dependencies, e)
+		}
+	}
+	for _, e := range epics {
+		result.Epics = append(result.Epics, *e)
+	}
+	sort.Slice(result.Epics, func(i, j int) bool { return result.Epics[i].Key < result.Epics[j].Key })
+	return result, nil
+}
+
+// datedSprint is the latest sprint of a finished issue, or the open sprint an unfinished issue is planned in, if the
+// sprint has dates
+func datedSprint(iss issue) (sprint, bool) {
+	s, open := scheduledSprint(iss)
+	if len(iss.Sprints) == 0 || (!open && !isDone(iss)) {
+		return sprint{}, false
+	}
+	return s, !s.StartDate.IsZero() && !s.EndDate.IsZero()
+}
+
+// iCalendar renders the epics' projected completion dates as all-day events
+func (t timeline) iCalendar(name string, now time.Time) string {
+	const dateLayout = "20060102"
+	escape := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+	var sb strings.Builder
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(&sb, format+"\r\n", args...)
+	}
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//jira-graph//timeline//EN")
+	line("X-WR-CALNAME:%s", escape.Replace(name+" projected completion"))
+	for _, e := range t.Epics {
+		day := e.ProjectedEnd.UTC()
+		line("BEGIN:VEVENT")
+		line("UID:%s@jira-graph", escape.Replace(e.Key))
+		line("DTSTAMP:%s", now.UTC().Format("20060102T150405Z"))
+		line("DTSTART;VALUE=DATE:%s", day.Format(dateLayout))
+		line("DTEND;VALUE=DATE:%s", day.AddDate(0, 0, 1).Format(dateLayout))
+		line("SUMMARY:%s", escape.Replace(fmt.Sprintf("%s %s projected completion", e.Key, e.Name)))
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return sb.String()
+}
@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_buildTimeline(t *testing.T) {
+	now := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return now.AddDate(0, 0, n) }
+	closed := sprint{ID: 1, State: "closed", Name: "Sprint 1", StartDate: day(-28), EndDate: day(-14)}
+	active := sprint{ID: 2, State: "active", Name: "Sprint 2", StartDate: day(-7), EndDate: day(7)}
+
+	issues := []issue{
+		{Key: "JG-1", Status: "Closed", EpicKey: "JG-10", EpicName: "Epic", Sprints: []sprint{closed}},
+		{Key: "JG-2", Status: "In Progress", EpicKey: "JG-10", Estimate: 3, Sprints: []sprint{active}, blockedByKeys: []string{"JG-1"}},
+		// carried over from a closed sprint, so it is back in the backlog
+		{Key: "JG-3", Status: "Backlog", EpicKey: "JG-11", EpicName: "Other", Estimate: 2, Sprints: []sprint{closed}},
+		{Key: "JG-4", Status: "Closed", Resolved: day(-3)},
+		// finished without a sprint or resolution date
+		{Key: "JG-5", Status: "Closed"},
+	}
+	resp := graphResponse{Issues: issues, Graph: issuesToBlocksGraph(issues)}
+	tl, err := buildTimeline(resp, scheduleOptions{developers: 1, rate: 1}, now)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []timelineItem{
+		{Key: "JG-1", Status: "Closed", EpicKey: "JG-10", Start: day(-28), End: day(-14), PlacedBy: placedBySprint, Sprint: "Sprint 1"},
+		{Key: "JG-2", Status: "In Progress", EpicKey: "JG-10", Start: day(-7), End: day(7), PlacedBy: placedBySprint, Sprint: "Sprint 2"},
+		{Key: "JG-3", Status: "Backlog", EpicKey: "JG-11", Start: day(3), End: day(5), PlacedBy: placedBySchedule},
+		{Key: "JG-4", Status: "Closed", Start: day(-3), End: day(-3), PlacedBy: placedByResolved},
+	}, tl.Items)
+	assert.Equal(t, [][2]string{{"JG-1", "JG-2"}}, tl.Dependencies)
+	assert.Equal(t, []timelineEpic{
+		{Key: "JG-10", Name: "Epic", ProjectedEnd: day(7)},
+		{Key: "JG-11", Name: "Other", ProjectedEnd: day(5)},
+	}, tl.Epics)
+}
+
+func Test_iCalendar(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 30, 0, 0, time.UTC)
+	tl := timeline{Epics: []timelineEpic{{Key: "JG-10", Name: "Search, v2; fast", ProjectedEnd: time.Date(2026, 4, 30, 18, 0, 0, 0, time.UTC)}}}
+
+	assert.Equal(t, "BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"PRODID:-//jira-graph//timeline//EN\r\n"+
+		"X-WR-CALNAME:JG-1 projected completion\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:JG-10@jira-graph\r\n"+
+		"DTSTAMP:20260302T123000Z\r\n"+
+		"DTSTART;VALUE=DATE:20260430\r\n"+
+		"DTEND;VALUE=DATE:20260501\r\n"+
+		`SUMMARY:JG-10 Search\, v2\; fast projected completion`+"\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n", tl.iCalendar("JG-1", now))
+}
@@ -0,0 +1,475 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+type tuiKey int
+
+const (
+	keyNone tuiKey = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyEnter
+	keyEscape
+	keyAssignee
+	keyStatus
+	keyClear
+	keyOpen
+	keyQuit
+)
+
+type tuiPane int
+
+const (
+	paneList tuiPane = iota
+	paneBlockedBy
+	paneBlocks
+)
+
+// tuiModel is the keyboard-driven state of the terminal UI, kept apart from terminal I/O
+type tuiModel struct {
+	title       string
+	jiraHost    string
+	resp        graphResponse
+	byKey       map[string]issue
+	layers      map[string]int
+	assignees   []string
+	statuses    []string
+	assignee    string
+	status      string
+	visible     []issue
+	cursor      int
+	focus       tuiPane
+	neighbor    int
+	showDetails bool
+	message     string
+}
+
+func newTUIModel(title, jiraHost string, resp graphResponse) *tuiModel {
+	m := &tuiModel{
+		title:    title,
+		jiraHost: jiraHost,
+		resp:     resp,
+		byKey:    map[string]issue{},
+		layers:   issueLayers(resp.Issues),
+	}
+
+	assignees := map[string]struct{}{}
+	statuses := map[string]struct{}{}
+	for _, iss := range resp.Issues {
+		m.byKey[iss.Key] = iss
+		assignees[iss.Assignee] = struct{}{}
+		statuses[iss.Status] = struct{}{}
+	}
+	m.assignees = sortedSet(assignees)
+	m.statuses = sortedSet(statuses)
+	m.applyFilters()
+	return m
+}
+
+func sortedSet(set map[string]struct{}) []string {
+	result := make([]string, 0, len(set))
+	for k := range set {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// applyFilters recomputes the visible issues in layer order, keeping the selection if it is still visible
+func (m *tuiModel) applyFilters() {
+	selected := m.selected()
+
+	m.visible = []issue{}
+	for _, iss := range m.resp.Issues {
+		if m.assignee != "" && iss.Assignee != m.assignee {
+			continue
+		}
+		if m.status != "" && iss.Status != m.status {
+			continue
+		}
+		m.visible = append(m.visible, iss)
+	}
+	sort.Slice(m.visible, func(i, j int) bool {
+		li, lj := m.layers[m.visible[i].Key], m.layers[m.visible[j].Key]
+		if li != lj {
+			return li < lj
+		}
+		return m.visible[i].Key < m.visible[j].Key
+	})
+
+	m.cursor = 0
+	for i := range m.visible {
+		if m.visible[i].Key == selected.Key {
+			m.cursor = i
+		}
+	}
+}
+
+func (m *tuiModel) selected() issue {
+	if m.cursor < 0 || m.cursor >= len(m.visible) {
+		return issue{}
+	}
+	return m.visible[m.cursor]
+}
+
+func (m *tuiModel) blockedBy() []string {
+	keys := append([]string{}, m.selected().blockedByKeys...)
+	sort.Strings(keys)
+	return keys
+}
+
+func (m *tuiModel) blocks() []string {
+	keys := append([]string{}, m.resp.Graph[m.selected().Key]...)
+	sort.Strings(keys)
+	return keys
+}
+
+func (m *tuiModel) neighbors() []string {
+	switch m.focus {
+	case paneBlockedBy:
+		return m.blockedBy()
+	case paneBlocks:
+		return m.blocks()
+	}
+	return nil
+}
+
+// follow selects the issue at the other end of the focused edge, clearing filters that would hide it
+func (m *tuiModel) follow() {
+	neighbors := m.neighbors()
+	if m.neighbor >= len(neighbors) {
+		return
+	}
+	key := neighbors[m.neighbor]
+	m.focus = paneList
+	m.neighbor = 0
+
+	if _, ok := m.byKey[key]; !ok {
+		m.message = fmt.Sprintf("%s is outside of this graph", key)
+		return
+	}
+	for i := range m.visible {
+		if m.visible[i].Key == key {
+			m.cursor = i
+			return
+		}
+	}
+	m.assignee, m.status = "", ""
+	m.applyFilters()
+	for i := range m.visible {
+		if m.visible[i].Key == key {
+			m.cursor = i
+		}
+	}
+}
+
+func nextInCycle(values []string, current string) string {
+	for i := range values {
+		if values[i] == current {
+			if i+1 < len(values) {
+				return values[i+1]
+			}
+			return ""
+		}
+	}
+	if len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// handleKey updates the model and reports whether the UI should exit
+func (m *tuiModel) handleKey(k tuiKey) bool {
+	m.message = ""
+	switch k {
+	case keyQuit:
+		return true
+	case keyUp:
+		if m.focus == paneList {
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		} else if m.neighbor > 0 {
+			m.neighbor--
+		}
+	case keyDown:
+		if m.focus == paneList {
+			if m.cursor < len(m.visible)-1 {
+				m.cursor++
+			}
+		} else if m.neighbor < len(m.neighbors())-1 {
+			m.neighbor++
+		}
+	case keyLeft:
+		switch m.focus {
+		case paneList:
+			if len(m.blockedBy()) == 0 {
+				m.message = "nothing blocks " + m.selected().Key
+				return false
+			}
+			m.focus, m.neighbor = paneBlockedBy, 0
+		case paneBlockedBy:
+			m.follow()
+		case paneBlocks:
+			m.focus = paneList
+		}
+	case keyRight:
+		switch m.focus {
+		case paneList:
+			if len(m.blocks()) == 0 {
+				m.message = m.selected().Key + " doesn't block anything"
+				return false
+			}
+			m.focus, m.neighbor = paneBlocks, 0
+		case paneBlocks:
+			m.follow()
+		case paneBlockedBy:
+			m.focus = paneList
+		}
+	case keyEnter:
+		if m.focus == paneList {
+			m.showDetails = !m.showDetails
+		} else {
+			m.follow()
+		}
+	case keyEscape:
+		m.focus = paneList
+		m.showDetails = false
+	case keyAssignee:
+		m.assignee = nextInCycle(m.assignees, m.assignee)
+		m.applyFilters()
+	case keyStatus:
+		m.status = nextInCycle(m.statuses, m.status)
+		m.applyFilters()
+	case keyClear:
+		m.assignee, m.status = "", ""
+		m.applyFilters()
+	case keyOpen:
+		if key := m.selected().Key; len(key) > 0 {
+			if err := openBrowser(browseURL(m.jiraHost, key)); err != nil {
+				m.message = fmt.Sprintf("failed to open %s: %v", key, err)
+			} else {
+				m.message = "opened " + key
+			}
+		}
+	}
+	return false
+}
+
+func (m *tuiModel) issueLine(iss issue, cursor bool) string {
+	prefix := "  "
+	if cursor {
+		prefix = "> "
+	}
+	flag := " "
+	if iss.Flagged {
+		flag = unicodeGlyphs.flagged
+	}
+	return fmt.Sprintf("%s%s %s %-10s %4s  %s  %s", prefix, unicodeGlyphs.status(iss.Status), flag, iss.Key,
+		strconv.FormatFloat(iss.Estimate, 'f', -1, 64), truncate(iss.Summary, 50, unicodeGlyphs.ellipsis), iss.Assignee)
+}
+
+func (m *tuiModel) neighborLines(keys []string, pane tuiPane) []string {
+	lines := []string{}
+	for i, key := range keys {
+		iss, ok := m.byKey[key]
+		if !ok {
+			iss = issue{Key: key, Summary: "(outside of this graph)"}
+		}
+		lines = append(lines, m.issueLine(iss, m.focus == pane && m.neighbor == i))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "  (none)")
+	}
+	return lines
+}
+
+func (m *tuiModel) detailLines(iss issue) []string {
+	sprintNames := make([]string, len(iss.Sprints))
+	for i := range iss.Sprints {
+		sprintNames[i] = iss.Sprints[i].Name
+	}
+	return []string{
+		fmt.Sprintf("%s  %s", iss.Key, iss.Summary),
+		fmt.Sprintf("  Type:     %s", iss.Type),
+		fmt.Sprintf("  Status:   %s", iss.Status),
+		fmt.Sprintf("  Assignee: %s", iss.Assignee),
+		fmt.Sprintf("  Estimate: %s", strconv.FormatFloat(iss.Estimate, 'f', -1, 64)),
+		fmt.Sprintf("  Priority: %s", iss.Priority),
+		fmt.Sprintf("  Flagged:  %t", iss.Flagged),
+		fmt.Sprintf("  Labels:   %s", strings.Join(iss.Labels, ", ")),
+		fmt.Sprintf("  Sprints:  %s", strings.Join(sprintNames, ", ")),
+		fmt.Sprintf("  Epic:     %s %s", iss.EpicKey, iss.EpicName),
+	}
+}
+
+// render draws the model into a screen of the given height
+func (m *tuiModel) render(height int) []string {
+	filters := []string{}
+	if m.assignee != "" {
+		filters = append(filters, "assignee: "+m.assignee)
+	}
+	if m.status != "" {
+		filters = append(filters, "status: "+m.status)
+	}
+	header := fmt.Sprintf("%s: %d of %d issues", m.title, len(m.visible), len(m.resp.Issues))
+	if len(filters) > 0 {
+		header += " [" + strings.Join(filters, ", ") + "]"
+	}
+	rule := strings.Repeat("─", 80)
+
+	bottom := []string{rule}
+	selected := m.selected()
+	if m.showDetails && len(selected.Key) > 0 {
+		bottom = append(bottom, m.detailLines(selected)...)
+		bottom = append(bottom, rule)
+	}
+	bottom = append(bottom, "Blocked by:")
+	bottom = append(bottom, m.neighborLines(m.blockedBy(), paneBlockedBy)...)
+	bottom = append(bottom, "Blocks:")
+	bottom = append(bottom, m.neighborLines(m.blocks(), paneBlocks)...)
+	bottom = append(bottom, rule, m.message,
+		"↑/↓ move  ←/→ blocked by/blocks  enter details/follow  a assignee  s status  c clear  o open  q quit")
+
+	listHeight := height - len(bottom) - 1
+	if listHeight < 3 {
+		listHeight = 3
+	}
+	start := 0
+	if m.cursor >= listHeight {
+		start = m.cursor - listHeight + 1
+	}
+
+	lines := []string{header}
+	for i := start; i < len(m.visible) && i < start+listHeight; i++ {
+		lines = append(lines, m.issueLine(m.visible[i], m.focus == paneList && i == m.cursor))
+	}
+	for len(lines) < listHeight+1 {
+		lines = append(lines, "")
+	}
+	return append(lines, bottom...)
+}
+
+func readKey(r *bufio.Reader) (tuiKey, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return keyNone, err
+	}
+	switch b {
+	case 'q', 3: // 3 is Ctrl-C in raw mode
+		return keyQuit, nil
+	case 'k':
+		return keyUp, nil
+	case 'j':
+		return keyDown, nil
+	case 'h':
+		return keyLeft, nil
+	case 'l':
+		return keyRight, nil
+	case '\r', '\n':
+		return keyEnter, nil
+	case 'a':
+		return keyAssignee, nil
+	case 's':
+		return keyStatus, nil
+	case 'c':
+		return keyClear, nil
+	case 'o':
+		return keyOpen, nil
+	case 0x1b:
+		if r.Buffered() == 0 {
+			return keyEscape, nil
+		}
+		seq := make([]byte, 2)
+		if _, err := io.ReadFull(r, seq); err != nil {
+			return keyNone, err
+		}
+		if seq[0] != '[' {
+			return keyNone, nil
+		}
+		switch seq[1] {
+		case 'A':
+			return keyUp, nil
+		case 'B':
+			return keyDown, nil
+		case 'C':
+			return keyRight, nil
+		case 'D':
+			return keyLeft, nil
+		}
+	}
+	return keyNone, nil
+}
+
+func openBrowser(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	return cmd.Start()
+}
+
+// RunTUI fetches the graph for an epic or milestone key and lets the user browse it interactively on the terminal
+func RunTUI(user, pass, jiraHost string, fc FieldConfig, key string) error {
+	useStatusCategories(fc)
+	jc := jiraClient{
+		host:        jiraHost,
+		user:        user,
+		pass:        pass,
+		fieldConfig: fc,
+	}
+	resp, err := getKeyGraph(jc, key)
+	if err != nil {
+		return err
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("the terminal UI requires an interactive terminal")
+	}
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, oldState)
+	// switch to the alternate screen and hide the cursor; undone on exit
+	fmt.Fprint(os.Stdout, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+
+	m := newTUIModel(key, jiraHost, resp)
+	in := bufio.NewReader(os.Stdin)
+	for {
+		_, height, err := term.GetSize(fd)
+		if err != nil {
+			height = 24
+		}
+		fmt.Fprint(os.Stdout, "\x1b[H\x1b[2J"+strings.Join(m.render(height), "\x1b[K\r\n"))
+
+		k, err := readKey(in)
+		if err != nil {
+			return err
+		}
+		if m.handleKey(k) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_tuiModel(t *testing.T) {
+	issues := []issue{
+		{Key: "JG-1", Assignee: "alice", Status: "Closed"},
+		{Key: "JG-2", Assignee: "bob", Status: "Backlog", blockedByKeys: []string{"JG-1"}},
+		{Key: "JG-3", Assignee: "alice", Status: "Backlog", blockedByKeys: []string{"JG-2"}},
+	}
+	resp := graphResponse{Issues: issues, Graph: issuesToBlocksGraph(issues)}
+
+	t.Run("follow blocked-by edges upstream", func(t *testing.T) {
+		m := newTUIModel("JG-0", "jira.example.com", resp)
+		m.handleKey(keyDown)
+		m.handleKey(keyDown)
+		assert.Equal(t, "JG-3", m.selected().Key)
+
+		m.handleKey(keyLeft)
+		assert.Equal(t, paneBlockedBy, m.focus)
+		m.handleKey(keyLeft)
+		assert.Equal(t, paneList, m.focus)
+		assert.Equal(t, "JG-2", m.selected().Key)
+	})
+
+	t.Run("following an edge clears filters hiding its target", func(t *testing.T) {
+		m := newTUIModel("JG-0", "jira.example.com", resp)
+		m.handleKey(keyAssignee)
+		assert.Equal(t, "alice", m.assignee)
+		assert.Len(t, m.visible, 2)
+
+		m.handleKey(keyRight)
+		m.handleKey(keyEnter)
+		assert.Equal(t, "JG-2", m.selected().Key)
+		assert.Equal(t, "", m.assignee)
+		assert.Len(t, m.visible, 3)
+	})
+
+	t.Run("issues without edges stay put", func(t *testing.T) {
+		m := newTUIModel("JG-0", "jira.example.com", resp)
+		m.handleKey(keyLeft)
+		assert.Equal(t, paneList, m.focus)
+		assert.Equal(t, "nothing blocks JG-1", m.message)
+	})
+}
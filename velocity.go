@@ -0,0 +1,157 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+const defaultVelocitySprints = 6
+
+type sprintVelocity struct {
+	Sprint sprint `json:"sprint"`
+	// Committed is every point that was in the sprint when it closed
+	Committed float64 `json:"committed"`
+	// Completed is the points of issues that were done by the time they left their last sprint
+	Completed float64 `json:"completed"`
+	// CarriedOver is the points of issues that moved from this sprint into a later one
+	CarriedOver float64 `json:"carriedOver"`
+}
+
+type boardVelocity struct {
+	BoardID int              `json:"boardId"`
+	Sprints []sprintVelocity `json:"sprints"`
+	Average float64          `json:"average"`
+}
+
+func (v boardVelocity) history() forecastHistory {
+	samples := make([]float64, len(v.Sprints))
+	sprints := make([]sprint, len(v.Sprints))
+	for i := range v.Sprints {
+		samples[i] = v.Sprints[i].Completed
+		sprints[i] = v.Sprints[i].Sprint
+	}
+	return forecastHistory{samples: samples, period: medianSprintLength(sprints)}
+}
+
+// getClosedSprints lists a board's closed sprints in the order they started
+func getClosedSprints(jc jiraClient, boardID int) ([]sprint, error) {
+	result := []sprint{}
+	for {
+		q := url.Values{
+			"state":   []string{"closed"},
+			"startAt": []string{strconv.Itoa(len(result))},
+		}
+		resp, err := jc.Get(fmt.Sprintf("/rest/agile/1.0/board/%d/sprint", boardID), q)
+		if err != nil {
+			return nil, err
+		}
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, errBadStatus{resp.StatusCode}
+		}
+
+		parsed := gjson.ParseBytes(b)
+		values := parsed.Get("values").Array()
+		for _, v := range values {
+			var s sprint
+			if err := json.Unmarshal([]byte(v.Raw), &s); err != nil {
+				return nil, err
+			}
+			s.BoardID = boardID
+			result = append(result, s)
+		}
+		if len(values) == 0 || parsed.Get("isLast").Bool() {
+			break
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].StartDate.Before(result[j].StartDate) })
+	return result, nil
+}
+
+// getBoardVelocity computes the velocity of a board's last n closed sprints from the issues that were in them
+func getBoardVelocity(jc jiraClient, boardID, n int) (boardVelocity, error) {
+	sprints, err := getClosedSprints(jc, boardID)
+	if err != nil {
+		return boardVelocity{}, err
+	}
+	if len(sprints) > n {
+		sprints = sprints[len(sprints)-n:]
+	}
+	if len(sprints) == 0 {
+		return boardVelocity{BoardID: boardID, Sprints: []sprintVelocity{}}, nil
+	}
+
+	ids := make([]string, len(sprints))
+	for i := range sprints {
+		ids[i] = strconv.Itoa(sprints[i].ID)
+	}
+	issues, err := searchIssues(jc, fmt.Sprintf("sprint IN (%s)", strings.Join(ids, ",")))
+	if err != nil {
+		return boardVelocity{}, err
+	}
+	return computeVelocity(boardID, sprints, issues), nil
+}
+
+func computeVelocity(boardID int, sprints []sprint, issues []issue) boardVelocity {
+	bySprint := make(map[int]*sprintVelocity, len(sprints))
+	result := boardVelocity{BoardID: boardID, Sprints: make([]sprintVelocity, len(sprints))}
+	for i := range sprints {
+		result.Sprints[i].Sprint = sprints[i]
+		bySprint[sprints[i].ID] = &result.Sprints[i]
+	}
+
+	for _, iss := range issues {
+		for i, s := range iss.Sprints {
+			v, ok := bySprint[s.ID]
+			if !ok {
+				continue
+			}
+			v.Committed += iss.Estimate
+			if i < len(iss.Sprints)-1 {
+				v.CarriedOver += iss.Estimate
+			} else if isDone(iss) {
+				v.Completed += iss.Estimate
+			}
+		}
+	}
+
+	total := 0.0
+	for _, v := range result.Sprints {
+		total += v.Completed
+	}
+	if len(result.Sprints) > 0 {
+		result.Average = total / float64(len(result.Sprints))
+	}
+	return result
+}
+
+// inferBoardID guesses the board an issue set is planned on, as the board of the most sprints in the issues
+func inferBoardID(issues []issue) (int, bool) {
+	counts := map[int]int{}
+	for _, iss := range issues {
+		for _, s := range iss.Sprints {
+			if s.BoardID > 0 {
+				counts[s.BoardID]++
+			}
+		}
+	}
+	best, bestCount := 0, 0
+	for id, count := range counts {
+		if count > bestCount || (count == bestCount && id < best) {
+			best, bestCount = id, count
+		}
+	}
+	return best, bestCount > 0
+}
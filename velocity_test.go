@@ -0,0 +1,25 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_computeVelocity(t *testing.T) {
+	s1 := sprint{ID: 1, State: "closed"}
+	s2 := sprint{ID: 2, State: "closed"}
+	s3 := sprint{ID: 3, State: "active"}
+	issues := []issue{
+		{Key: "JG-1", Status: "Closed", Estimate: 3, Sprints: []sprint{s1}},
+		{Key: "JG-2", Status: "Closed", Estimate: 5, Sprints: []sprint{s1, s2}},
+		{Key: "JG-3", Status: "In Progress", Estimate: 2, Sprints: []sprint{s2, s3}},
+		{Key: "JG-4", Status: "In Progress", Estimate: 1, Sprints: []sprint{s2}},
+	}
+
+	v := computeVelocity(7, []sprint{s1, s2}, issues)
+	assert.Equal(t, 7, v.BoardID)
+	assert.Equal(t, sprintVelocity{Sprint: s1, Committed: 8, Completed: 3, CarriedOver: 5}, v.Sprints[0])
+	assert.Equal(t, sprintVelocity{Sprint: s2, Committed: 8, Completed: 5, CarriedOver: 2}, v.Sprints[1])
+	assert.Equal(t, 4.0, v.Average)
+}
@@ -0,0 +1,109 @@
+package graph
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// maxWebhookBody bounds how much of a webhook request is read
+const maxWebhookBody = 1 << 20
+
+// validWebhookSignature checks a Jira webhook's 'X-Hub-Signature' header, the hex HMAC-SHA256 of the body keyed with
+// the shared secret
+func validWebhookSignature(secret string, body []byte, header string) bool {
+	sig, err := hex.DecodeString(strings.TrimPrefix(header, "sha256="))
+	if err != nil || len(sig) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+// webhookKeys returns the keys of the issues and epics that a webhook event affects. Issue events name the issue,
+// its epic, and any epic it was just moved out of. Link events only carry issue IDs, which are resolved with Jira;
+// issues that no longer exist, e.g. a deleted issue whose links were removed with it, are skipped.
+func (gc graphController) webhookKeys(payload gjson.Result) (map[string]bool, error) {
+	keys := map[string]bool{}
+	add := func(k string) {
+		if len(k) > 0 {
+			keys[k] = true
+		}
+	}
+
+	event := payload.Get("webhookEvent").String()
+	switch {
+	case strings.HasPrefix(event, "jira:issue_"):
+		iss := gc.jc.unmarshallLinkedIssue(payload.Get("issue"))
+		add(iss.Key)
+		add(iss.EpicKey)
+		epicLink := fieldMatcher{id: gc.jc.fieldConfig.EpicLink, name: gc.jc.fieldConfig.EpicLink}
+		for _, item := range payload.Get("changelog.items").Array() {
+			c := change{Field: item.Get("field").String(), FieldID: item.Get("fieldId").String()}
+			if epicLink.matches(c) {
+				add(item.Get("fromString").String())
+				add(item.Get("toString").String())
+			}
+		}
+	case strings.HasPrefix(event, "issuelink_"):
+		for _, id := range []string{payload.Get("issueLink.sourceIssueId").String(), payload.Get("issueLink.destinationIssueId").String()} {
+			if len(id) == 0 {
+				continue
+			}
+			iss, err := getSingleIssue(gc.jc, id)
+			if err != nil && isUnavailable(err) {
+				return nil, err
+			} else if err != nil {
+				log.Printf("skipping unresolvable issue %s of a link event: %v", id, err)
+				continue
+			}
+			add(iss.Key)
+			add(iss.EpicKey)
+		}
+	}
+	return keys, nil
+}
+
+// receiveWebhook invalidates the cached issues and graphs affected by a Jira issue or issue link event, and wakes the
+// graph streams watching them
+func (gc graphController) receiveWebhook(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := ioutil.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": http.StatusText(http.StatusBadRequest)})
+			return
+		}
+		if !validWebhookSignature(secret, body, c.GetHeader("X-Hub-Signature")) {
+			c.JSON(http.StatusUnauthorized, gin.H{"status": http.StatusText(http.StatusUnauthorized)})
+			return
+		}
+
+		keys, err := gc.webhookKeys(gjson.ParseBytes(body))
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+		invalidated := []string{}
+		if gc.cache != nil && len(keys) > 0 {
+			invalidated, err = gc.cache.invalidate(keys)
+			if err != nil {
+				log.Printf("failed to invalidate the cache: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"status": http.StatusText(http.StatusInternalServerError)})
+				return
+			}
+		}
+		gc.events.notify(keys)
+		sort.Strings(invalidated)
+		c.JSON(http.StatusOK, gin.H{"invalidated": invalidated})
+	}
+}
@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func Test_validWebhookSignature(t *testing.T) {
+	body := []byte(`{"webhookEvent":"jira:issue_updated"}`)
+	// echo -n '{"webhookEvent":"jira:issue_updated"}' | openssl dgst -sha256 -hmac secret
+	sig := "sha256=485f67f073e321374184d9f9d02ca6ca803d74e8089d6e0a2910be28d1abe821"
+	assert.True(t, validWebhookSignature("secret", body, sig))
+	assert.False(t, validWebhookSignature("other", body, sig))
+	assert.False(t, validWebhookSignature("secret", body, ""))
+}
+
+// webhook payloads as Jira sends them, trimmed to the parts that are read
+const (
+	issueMovedPayload = `{
+		"timestamp": 1760000000000,
+		"webhookEvent": "jira:issue_updated",
+		"issue_event_type_name": "issue_generic",
+		"issue": {"id": "10002", "key": "JG-2", "fields": {"issuetype": {"name": "Story"}, "status": {"name": "Backlog"}, "customfield_10014": "JG-5"}},
+		"changelog": {"id": "10400", "items": [
+			{"field": "Epic Link", "fieldtype": "custom", "fieldId": "customfield_10014", "from": "10001", "fromString": "JG-1", "to": "10005", "toString": "JG-5"}
+		]}
+	}`
+	issueLinkPayload = `{
+		"timestamp": 1760000000000,
+		"webhookEvent": "issuelink_created",
+		"issueLink": {"id": 10100, "sourceIssueId": 10002, "destinationIssueId": 10003, "issueLinkType": {"id": 10000, "name": "Blocks"}}
+	}`
+	deletedIssueLinkPayload = `{
+		"timestamp": 1760000000000,
+		"webhookEvent": "issuelink_deleted",
+		"issueLink": {"id": 10101, "sourceIssueId": 10002, "destinationIssueId": 10099, "issueLinkType": {"id": 10000, "name": "Blocks"}}
+	}`
+)
+
+func webhookJira(t *testing.T) jiraClient {
+	return fakeJira{searches: map[string]string{
+		`id=10002`: "[" + fakeIssue("JG-2", "Story", "JG-1") + "]",
+		`id=10003`: "[" + fakeIssue("JG-3", "Story", "JG-7") + "]",
+		// Jira answers searches for a deleted issue's ID with a 400, as for any other unknown ID
+	}, epics: map[string]string{"JG-1": "Epic", "JG-7": "Other"}}.client(t)
+}
+
+func Test_webhookKeys(t *testing.T) {
+	gc := graphController{jc: webhookJira(t)}
+	tests := []struct {
+		name     string
+		payload  string
+		expected map[string]bool
+	}{
+		{"issue moved between epics", issueMovedPayload, map[string]bool{"JG-2": true, "JG-1": true, "JG-5": true}},
+		{"issue link", issueLinkPayload, map[string]bool{"JG-2": true, "JG-1": true, "JG-3": true, "JG-7": true}},
+		{"link to a deleted issue", deletedIssueLinkPayload, map[string]bool{"JG-2": true, "JG-1": true}},
+		{"other events", `{"webhookEvent": "sprint_started"}`, map[string]bool{}},
+	}
+	for _, tt := range tests {
+		keys, err := gc.webhookKeys(gjson.Parse(tt.payload))
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.expected, keys, tt.name)
+	}
+}
+
+func Test_receiveWebhook(t *testing.T) {
+	rc := newResponseCache(t.TempDir(), 0)
+	issues := []issue{{Key: "JG-2", EpicKey: "JG-1"}}
+	assert.NoError(t, rc.store(epicGraphs.cacheKind, "JG-1", graphResponse{Issues: issues, Graph: issuesToBlocksGraph(issues)}))
+	gc := graphController{jc: webhookJira(t), cache: rc, events: newGraphEvents()}
+	r := gin.New()
+	r.POST("/webhooks/jira", gc.receiveWebhook("secret"))
+
+	post := func(body, signature string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/jira", strings.NewReader(body))
+		req.Header.Set("X-Hub-Signature", signature)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(deletedIssueLinkPayload))
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	w := post(deletedIssueLinkPayload, "sha256=00")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	_, ok := rc.load(epicGraphs.cacheKind, "JG-1", &graphResponse{})
+	assert.True(t, ok)
+
+	w = post(deletedIssueLinkPayload, signature)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"invalidated": ["JG-1"]}`, w.Body.String())
+	_, ok = rc.load(epicGraphs.cacheKind, "JG-1", &graphResponse{})
+	assert.False(t, ok)
+}